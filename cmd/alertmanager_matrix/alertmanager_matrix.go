@@ -3,46 +3,237 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gopkg.in/yaml.v3"
 
 	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
 	bot2 "github.com/silkeh/alertmanager_matrix/pkg/bot"
 )
 
-func requestHandler(client *bot2.Client, alertLabels bool, w http.ResponseWriter, r *http.Request) {
+func requestHandler(client *bot2.Client, w http.ResponseWriter, r *http.Request) {
+	// Verify the credentials before resolving the room, to avoid directory lookups for unauthorized requests
+	if !client.WebhookAuthorized(r) {
+		unauthorized(w, r)
+		webhooksTotal.WithLabelValues("", "", strconv.Itoa(http.StatusUnauthorized)).Inc()
+
+		return
+	}
+
 	// Get room from request
-	room := client.Matrix.NewRoom(mux.Vars(r)["room"])
-	if room.ID == "" || room.ID[0] != '!' {
-		log.Printf("Invalid room ID: %q", room.ID)
-		w.WriteHeader(http.StatusBadRequest)
+	roomID, err := client.ResolveRoom(mux.Vars(r)["room"])
+	if err != nil {
+		log.Printf("Error resolving room: %s", err)
+
+		status := http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, bot2.ErrInvalidRoom):
+			status = http.StatusBadRequest
+		case errors.Is(err, bot2.ErrUnknownRoom):
+			status = http.StatusNotFound
+		}
+
+		webhooksTotal.WithLabelValues("", "", strconv.Itoa(status)).Inc()
+		w.WriteHeader(status)
+
+		return
+	}
+
+	handleWebhook(client, w, r, []*bot2.WebhookAuth{client.RoomConfig(roomID).WebhookAuth}, roomRouter(roomID))
+}
+
+func routeHandler(client *bot2.Client, w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["route"]
+
+	// Verify the credentials of the route before resolving its rooms
+	if auth, ok := client.RouteWebhookAuth(name); ok && !auth.Authorized(r) {
+		unauthorized(w, r)
+		webhooksTotal.WithLabelValues("", "", strconv.Itoa(http.StatusUnauthorized)).Inc()
+
+		return
+	}
+
+	// Get route from request
+	route, ok, err := client.Route(name)
+	if err != nil || !ok {
+		status := http.StatusNotFound
+
+		if err != nil {
+			log.Printf("Error resolving route: %s", err)
+
+			status = http.StatusInternalServerError
+		}
+
+		webhooksTotal.WithLabelValues("", "", strconv.Itoa(status)).Inc()
+		w.WriteHeader(status)
+
+		return
+	}
+
+	// Rooms with credentials of their own require those in addition to the credentials of the route
+	auths := []*bot2.WebhookAuth{route.WebhookAuth}
+	for _, id := range route.Rooms {
+		auths = append(auths, client.RoomWebhookAuth(id))
+	}
+
+	handleWebhook(client, w, r, auths, roomRouter(route.Rooms...))
+}
+
+func matcherRouteHandler(client *bot2.Client, w http.ResponseWriter, r *http.Request) {
+	// The configuration of an unknown room contains the global credentials
+	handleWebhook(client, w, r, []*bot2.WebhookAuth{client.RoomConfig("").WebhookAuth}, client.RouteAlerts)
+}
+
+// authorized returns true if the request provides all given credentials.
+func authorized(r *http.Request, auths []*bot2.WebhookAuth) bool {
+	for _, auth := range auths {
+		if !auth.Authorized(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unauthorized responds to a request that does not provide the required credentials.
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Unauthorized request for %s from %s", r.URL.Path, r.RemoteAddr)
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="alertmanager_matrix"`)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// router returns the message to send per room ID for a webhook message.
+type router func(message *alertmanager.Message) (map[string]*alertmanager.Message, error)
+
+// roomRouter returns a router that sends the complete message to all given rooms.
+func roomRouter(roomIDs ...string) router {
+	return func(message *alertmanager.Message) (map[string]*alertmanager.Message, error) {
+		messages := make(map[string]*alertmanager.Message, len(roomIDs))
+		for _, id := range roomIDs {
+			messages[id] = message
+		}
+
+		return messages, nil
+	}
+}
+
+// handleWebhook sends the alerts in a webhook request to the rooms returned by the router.
+// The request must provide all given credentials.
+func handleWebhook(client *bot2.Client, w http.ResponseWriter, r *http.Request,
+	auths []*bot2.WebhookAuth, route router,
+) {
+	var receiver string
+
+	status := http.StatusOK
+	statuses := make(map[string]int)
+
+	defer func() {
+		if len(statuses) == 0 {
+			webhooksTotal.WithLabelValues("", receiver, strconv.Itoa(status)).Inc()
+		}
+
+		// Room IDs are taken from requests, so only configured rooms are counted by ID
+		for roomID, status := range statuses {
+			if !client.ConfiguredRoom(roomID) {
+				roomID = otherRoom
+			}
+
+			webhooksTotal.WithLabelValues(roomID, receiver, strconv.Itoa(status)).Inc()
+		}
+	}()
+
+	// Verify the credentials for the rooms
+	if !authorized(r, auths) {
+		status = http.StatusUnauthorized
+		unauthorized(w, r)
 
 		return
 	}
 
+	// Get the notification mode from the request, if given
+	var mode bot2.NotifyMode
+
+	if str := r.URL.Query().Get("mode"); str != "" {
+		var err error
+
+		mode, err = bot2.ParseNotifyMode(str)
+		if err != nil {
+			log.Printf("Error parsing request: %s", err)
+
+			status = http.StatusBadRequest
+			w.WriteHeader(status)
+
+			return
+		}
+	}
+
 	// Parse the message
 	data := new(alertmanager.Message)
 	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
 		log.Printf("Error parsing message: %s", err)
-		w.WriteHeader(http.StatusBadRequest)
+
+		status = http.StatusBadRequest
+		w.WriteHeader(status)
 
 		return
 	}
 
-	// Create readable messages for Matrix
-	plain, html := client.Formatter.FormatAlerts(data.Alerts, alertLabels)
-	log.Printf("Sending message to %s: %s", room.ID, plain)
+	receiver = data.Receiver
+	webhookAlerts.Observe(float64(len(data.Alerts)))
 
-	if _, err := room.SendHTML(plain, html); err != nil {
-		log.Printf("Error sending message: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	messages, err := route(data)
+	if err != nil {
+		log.Printf("Error routing message: %s", err)
+
+		status = http.StatusInternalServerError
+		w.WriteHeader(status)
+
+		return
+	}
+
+	// Rooms with credentials of their own require those, regardless of the route to them
+	for roomID := range messages {
+		if !client.RoomWebhookAuth(roomID).Authorized(r) {
+			log.Printf("Unauthorized request for %s from %s: room %s requires its own credentials",
+				r.URL.Path, r.RemoteAddr, roomID)
+
+			status = http.StatusUnauthorized
+			w.Header().Set("WWW-Authenticate", `Basic realm="alertmanager_matrix"`)
+			w.WriteHeader(status)
+
+			return
+		}
+	}
+
+	if len(messages) == 0 {
+		log.Printf("No route for %s notification with %d alert(s)", data.Status, len(data.Alerts))
+	}
+
+	for roomID, message := range messages {
+		log.Printf("Sending %s notification for %d alert(s) to %s", message.Status, len(message.Alerts), roomID)
+
+		statuses[roomID] = http.StatusOK
+
+		if err := client.Notify(roomID, message, mode); err != nil {
+			log.Printf("Error sending message: %s", err)
+
+			status = http.StatusInternalServerError
+			statuses[roomID] = status
+		}
 	}
+
+	w.WriteHeader(status)
 }
 
 func setStringFromEnv(target *string, env string) {
@@ -51,19 +242,19 @@ func setStringFromEnv(target *string, env string) {
 	}
 }
 
-func loadFile(fileName string) string {
+func loadFile(fileName string) (string, error) {
 	contents, err := os.ReadFile(fileName) //nolint:gosec // contents inclusion is the point
 	if err != nil {
-		log.Fatalf("Unable to read file %q: %s", fileName, err)
+		return "", fmt.Errorf("unable to read file %q: %w", fileName, err)
 	}
 
-	return string(contents)
+	return string(contents), nil
 }
 
-func mapFromYAMLFile(fileName string) map[string]string {
+func mapFromYAMLFile(fileName string) (map[string]string, error) {
 	file, err := os.Open(fileName) //nolint:gosec // file inclusion is the point
 	if err != nil {
-		log.Fatalf("Unable to open YAML file %q: %s", fileName, err)
+		return nil, fmt.Errorf("unable to open YAML file %q: %w", fileName, err)
 	}
 
 	m := make(map[string]string)
@@ -72,75 +263,110 @@ func mapFromYAMLFile(fileName string) map[string]string {
 	if err != nil {
 		_ = file.Close()
 
-		log.Fatalf("Unable to parse YAML file %q: %s", fileName, err)
+		return nil, fmt.Errorf("unable to parse YAML file %q: %w", fileName, err)
 	}
 
 	_ = file.Close()
 
-	return m
+	return m, nil
 }
 
-func formatter(colorFile, iconFile, htmlTemplateFile, textTemplateFile string) *bot2.Formatter {
+func loadTemplates(textTemplateFile, htmlTemplateFile string) (textTemplate, htmlTemplate string, err error) {
+	if textTemplateFile != "" {
+		if textTemplate, err = loadFile(textTemplateFile); err != nil {
+			return "", "", err
+		}
+	}
+
+	if htmlTemplateFile != "" {
+		if htmlTemplate, err = loadFile(htmlTemplateFile); err != nil {
+			return "", "", err
+		}
+	}
+
+	return textTemplate, htmlTemplate, nil
+}
+
+func formatter(config *FormatterConfig) (*bot2.Formatter, error) {
 	var (
-		colors, icons              map[string]string
-		htmlTemplate, textTemplate string
+		colors, icons map[string]string
+		err           error
 	)
 
-	if colorFile != "" {
-		colors = mapFromYAMLFile(colorFile)
+	if config.ColorFile != "" {
+		if colors, err = mapFromYAMLFile(config.ColorFile); err != nil {
+			return nil, err
+		}
 	}
 
-	if iconFile != "" {
-		icons = mapFromYAMLFile(iconFile)
+	if config.IconFile != "" {
+		if icons, err = mapFromYAMLFile(config.IconFile); err != nil {
+			return nil, err
+		}
 	}
 
-	if htmlTemplateFile != "" {
-		htmlTemplate = loadFile(htmlTemplateFile)
+	textTemplate, htmlTemplate, err := loadTemplates(config.TextTemplateFile, config.HTMLTemplateFile)
+	if err != nil {
+		return nil, err
 	}
 
-	if textTemplateFile != "" {
-		textTemplate = loadFile(textTemplateFile)
+	f, err := bot2.ParseFormatter(textTemplate, htmlTemplate, colors, icons)
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates: %w", err)
 	}
 
-	return bot2.NewFormatter(textTemplate, htmlTemplate, colors, icons)
+	textTemplate, htmlTemplate, err = loadTemplates(config.ListTextTemplateFile, config.ListHTMLTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.ParseListTemplates(textTemplate, htmlTemplate); err != nil {
+		return nil, fmt.Errorf("invalid templates: %w", err)
+	}
+
+	textTemplate, htmlTemplate, err = loadTemplates(config.DetailTextTemplateFile, config.DetailHTMLTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.ParseDetailTemplates(textTemplate, htmlTemplate); err != nil {
+		return nil, fmt.Errorf("invalid templates: %w", err)
+	}
+
+	return f, nil
 }
 
 func main() {
-	var addr, iconFile, colorFile, htmlTemplateFile, textTemplateFile string
-
-	config := bot2.ClientConfig{}
-	alertLabels := false
-
-	flag.StringVar(&addr, "addr", ":4051", "Address to listen on.")
-	flag.StringVar(&config.Homeserver, "homeserver", "http://localhost:8008", "Homeserver to connect to.")
-	flag.StringVar(&config.UserID, "userID", "", "User ID to connect with.")
-	flag.StringVar(&config.Token, "token", "", "Token to connect with.")
-	flag.StringVar(&config.Rooms, "rooms", "", "Comma separated list of allowed rooms. All rooms are allowed by default.")
-	flag.StringVar(&config.AlertManagerURL, "alertmanager", "http://localhost:9093", "Alertmanager to connect to.")
-	flag.StringVar(&config.MessageType, "message-type", "m.notice", "Type of message the bot uses.")
-	flag.StringVar(&iconFile, "icon-file", "", "YAML file with icons for message types.")
-	flag.StringVar(&colorFile, "color-file", "", "YAML file with colors for message types.")
-	flag.StringVar(&htmlTemplateFile, "html-template", "", "HTML template for alert messages.")
-	flag.StringVar(&textTemplateFile, "text-template", "", "Plain-text template for alert messages.")
-	flag.BoolVar(&alertLabels, "show-labels", false, "show labels of alerts messages.")
+	var configFile string
+
+	defaultConfig().bindFlags(flag.CommandLine, &configFile)
 	flag.Parse()
 
-	// Set variables from the environment
-	setStringFromEnv(&addr, "ADDR")
-	setStringFromEnv(&config.Homeserver, "HOMESERVER")
-	setStringFromEnv(&config.UserID, "USER_ID")
-	setStringFromEnv(&config.Token, "TOKEN")
-	setStringFromEnv(&config.AlertManagerURL, "ALERTMANAGER")
-	setStringFromEnv(&config.Rooms, "ROOMS")
+	setStringFromEnv(&configFile, "CONFIG")
+
+	config, err := loadConfig(configFile)
+	if err != nil {
+		log.Fatalf("Error: %s", err)
+	}
 
 	if config.UserID == "" || config.Token == "" {
 		log.Fatal("Error: user ID or token not supplied")
 	}
 
-	log.Printf("Connecting to Matrix homeserver at %s as %s, and to Alertmanager at %s",
-		config.Homeserver, config.UserID, config.AlertManagerURL)
+	if len(config.Alertmanagers) > 0 {
+		log.Printf("Connecting to Matrix homeserver at %s as %s, and to %d Alertmanagers",
+			config.Homeserver, config.UserID, len(config.Alertmanagers))
+	} else {
+		log.Printf("Connecting to Matrix homeserver at %s as %s, and to Alertmanager at %s",
+			config.Homeserver, config.UserID, config.AlertManagerURL)
+	}
 
-	client, err := bot2.NewClient(&config, formatter(colorFile, iconFile, htmlTemplateFile, textTemplateFile))
+	clientConfig, formatter, err := config.clientConfig()
+	if err != nil {
+		log.Fatalf("Error: %s", err)
+	}
+
+	client, err := bot2.NewClient(clientConfig, formatter)
 	if err != nil {
 		log.Fatalf("Error connecting to Matrix: %s", err)
 	}
@@ -150,17 +376,38 @@ func main() {
 		log.Fatal(client.Run())
 	}()
 
-	// Create the HTTP handler
+	// Create the HTTP handlers
 	handler := func(w http.ResponseWriter, r *http.Request) {
-		requestHandler(client, alertLabels, w, r)
+		requestHandler(client, w, r)
+	}
+
+	webhookRouteHandler := func(w http.ResponseWriter, r *http.Request) {
+		routeHandler(client, w, r)
 	}
 
+	webhookMatcherHandler := func(w http.ResponseWriter, r *http.Request) {
+		matcherRouteHandler(client, w, r)
+	}
+
+	// Create the configuration reloader
+	reloader := &reloader{client: client, config: config, configFile: configFile, token: config.ReloadToken}
+
 	// Create/start HTTP server
 	r := mux.NewRouter()
-	server := &http.Server{Addr: addr, Handler: r, ReadTimeout: time.Second}
+	server := &http.Server{Addr: config.Addr, Handler: r, ReadTimeout: time.Second}
 
+	r.HandleFunc("/", webhookMatcherHandler).Methods("POST")
 	r.HandleFunc("/{room}", handler).Methods("POST")
+	r.HandleFunc("/route/{route}", webhookRouteHandler).Methods("POST")
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	if config.ReloadToken != "" {
+		r.Handle("/-/reload", reloader).Methods("POST")
+	}
+
+	// Reload the configuration on SIGHUP
+	go reloader.handleSignals()
 
-	log.Print("Listening on ", addr)
-	log.Fatal(server.ListenAndServe())
+	log.Print("Listening on ", server.Addr)
+	log.Fatal(listenAndServe(server, config.WebConfigFile))
 }
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+	bot2 "github.com/silkeh/alertmanager_matrix/pkg/bot"
+)
+
+// Config represents the configuration of the application.
+// It can be loaded from a YAML file, and overridden by flags and environment variables.
+type Config struct {
+	Addr                string                         `yaml:"addr"`
+	Homeserver          string                         `yaml:"homeserver"`
+	UserID              string                         `yaml:"user_id"`
+	Token               string                         `yaml:"token"`
+	Rooms               string                         `yaml:"allowed_rooms"`
+	AlertManagerURL     string                         `yaml:"alertmanager"`
+	AlertManagerHTTP    *alertmanager.HTTPConfig       `yaml:"alertmanager_http_config"`
+	Alertmanagers       map[string]*AlertmanagerConfig `yaml:"alertmanagers"`
+	DefaultAlertmanager string                         `yaml:"default_alertmanager"`
+	MessageType         string                         `yaml:"message_type"`
+	StateFile           string                         `yaml:"state_file"`
+	NotifyMode          string                         `yaml:"notify_mode"`
+	QueueSize           int                            `yaml:"queue_size"`
+	RetryMaxAge         string                         `yaml:"retry_max_age"`
+	DedupWindow         string                         `yaml:"dedup_window"`
+	SilenceReaction     string                         `yaml:"silence_reaction"`
+	SilenceDuration     string                         `yaml:"silence_duration"`
+	SilenceConfirm      int                            `yaml:"silence_confirm_threshold"`
+	AckDuration         string                         `yaml:"ack_duration"`
+	ShowLabels          bool                           `yaml:"show_labels"`
+	ReloadToken         string                         `yaml:"reload_token"`
+	WebConfigFile       string                         `yaml:"web_config_file"`
+	WebhookAuth         AuthConfig                     `yaml:"webhook_auth"`
+	Confirm             ConfirmConfig                  `yaml:"confirm"`
+	Formatter           FormatterConfig                `yaml:",inline"`
+	RoomConfigs         map[string]*RoomConfig         `yaml:"rooms"`
+	Routes              []*RouteConfig                 `yaml:"routes"`
+}
+
+// FormatterConfig contains the files used for formatting alert messages.
+type FormatterConfig struct {
+	IconFile               string `yaml:"icon_file"`
+	ColorFile              string `yaml:"color_file"`
+	HTMLTemplateFile       string `yaml:"html_template_file"`
+	TextTemplateFile       string `yaml:"text_template_file"`
+	ListHTMLTemplateFile   string `yaml:"list_html_template_file"`
+	ListTextTemplateFile   string `yaml:"list_text_template_file"`
+	DetailHTMLTemplateFile string `yaml:"detail_html_template_file"`
+	DetailTextTemplateFile string `yaml:"detail_text_template_file"`
+}
+
+// RoomConfig contains the configuration for a single room.
+// Unset values default to the global configuration.
+type RoomConfig struct {
+	Formatter    FormatterConfig `yaml:",inline"`
+	MessageType  string          `yaml:"message_type"`
+	ShowLabels   *bool           `yaml:"show_labels"`
+	NotifyMode   string          `yaml:"notify_mode"`
+	Commands     []string        `yaml:"commands"`
+	WebhookAuth  AuthConfig      `yaml:"webhook_auth"`
+	Alertmanager string          `yaml:"alertmanager"`
+}
+
+// AlertmanagerConfig contains the configuration of a named Alertmanager.
+// Replicas of an Alertmanager cluster can be configured using URLs.
+type AlertmanagerConfig struct {
+	URL        string                   `yaml:"url"`
+	URLs       []string                 `yaml:"urls"`
+	HTTPConfig *alertmanager.HTTPConfig `yaml:"http_config"`
+}
+
+// RouteConfig contains the configuration of a route for alerts.
+type RouteConfig struct {
+	Name        string     `yaml:"name"`
+	Rooms       []string   `yaml:"rooms"`
+	Matchers    []string   `yaml:"matchers"`
+	Continue    bool       `yaml:"continue"`
+	WebhookAuth AuthConfig `yaml:"webhook_auth"`
+}
+
+// AuthConfig contains the credentials required for webhooks.
+type AuthConfig struct {
+	BearerToken string          `yaml:"bearer_token"`
+	BasicAuth   BasicAuthConfig `yaml:"basic_auth"`
+}
+
+// ConfirmConfig contains the commands that require confirmation before they are executed.
+type ConfirmConfig struct {
+	Timeout  string         `yaml:"timeout"`
+	Commands map[string]int `yaml:"commands"`
+}
+
+// BasicAuthConfig contains credentials for HTTP basic authentication.
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// defaultConfig returns the default configuration.
+func defaultConfig() *Config {
+	return &Config{
+		Addr:             ":4051",
+		Homeserver:       "http://localhost:8008",
+		AlertManagerURL:  "http://localhost:9093",
+		AlertManagerHTTP: alertmanager.DefaultHTTPConfig(),
+		MessageType:      "m.notice",
+		NotifyMode:       string(bot2.NotifyMessage),
+		SilenceReaction:  "🔕",
+		SilenceDuration:  "1d",
+		AckDuration:      "1h",
+		QueueSize:        100,
+		RetryMaxAge:      "1h",
+	}
+}
+
+// bindFlags defines the command line flags in a flag set, with the values in the configuration as defaults.
+// The path of the configuration file is parsed into configFile.
+func (c *Config) bindFlags(flags *flag.FlagSet, configFile *string) {
+	flags.StringVar(configFile, "config", "", "YAML configuration file.")
+	flags.StringVar(&c.Addr, "addr", c.Addr, "Address to listen on.")
+	flags.StringVar(&c.Homeserver, "homeserver", c.Homeserver, "Homeserver to connect to.")
+	flags.StringVar(&c.UserID, "userID", c.UserID, "User ID to connect with.")
+	flags.StringVar(&c.Token, "token", c.Token, "Token to connect with.")
+	flags.StringVar(&c.Rooms, "rooms", c.Rooms,
+		"Comma separated list of allowed rooms. All rooms are allowed by default.")
+	flags.StringVar(&c.AlertManagerURL, "alertmanager", c.AlertManagerURL,
+		"Alertmanager to connect to. Replicas of a cluster can be given as a comma separated list.")
+	flags.StringVar(&c.StateFile, "state-file", c.StateFile, "File to persist the state of sent messages in.")
+	flags.StringVar(&c.NotifyMode, "notify-mode", c.NotifyMode,
+		"How to send follow-up notifications for alert groups: message, thread or edit.")
+	flags.IntVar(&c.QueueSize, "queue-size", c.QueueSize,
+		"Maximum number of failed notifications that are queued for retrying.")
+	flags.StringVar(&c.RetryMaxAge, "retry-max-age", c.RetryMaxAge,
+		"Duration after which failed notifications are no longer retried.")
+	flags.StringVar(&c.DedupWindow, "dedup-window", c.DedupWindow,
+		"Duration in which identical notifications, eg: from multiple Alertmanager replicas, are ignored. "+
+			"Disabled if empty.")
+	flags.StringVar(&c.SilenceReaction, "silence-reaction", c.SilenceReaction,
+		"Reaction for silencing the alerts in a message. Set to an empty string to disable.")
+	flags.StringVar(&c.SilenceDuration, "silence-duration", c.SilenceDuration,
+		"Duration of silences created by reactions.")
+	flags.IntVar(&c.SilenceConfirm, "silence-confirm-threshold", c.SilenceConfirm,
+		"Number of firing alerts a new silence can match before it requires confirmation. Disabled if 0.")
+	flags.StringVar(&c.AckDuration, "ack-duration", c.AckDuration,
+		"Duration of acknowledgements before they are extended.")
+	flags.StringVar(&c.MessageType, "message-type", c.MessageType, "Type of message the bot uses.")
+	flags.StringVar(&c.Formatter.IconFile, "icon-file", c.Formatter.IconFile,
+		"YAML file with icons for message types.")
+	flags.StringVar(&c.Formatter.ColorFile, "color-file", c.Formatter.ColorFile,
+		"YAML file with colors for message types.")
+	flags.StringVar(&c.Formatter.HTMLTemplateFile, "html-template", c.Formatter.HTMLTemplateFile,
+		"HTML template for alert messages.")
+	flags.StringVar(&c.Formatter.TextTemplateFile, "text-template", c.Formatter.TextTemplateFile,
+		"Plain-text template for alert messages.")
+	flags.StringVar(&c.Formatter.ListHTMLTemplateFile, "list-html-template", c.Formatter.ListHTMLTemplateFile,
+		"HTML template file for summaries of alert lists.")
+	flags.StringVar(&c.Formatter.ListTextTemplateFile, "list-text-template", c.Formatter.ListTextTemplateFile,
+		"Text template file for summaries of alert lists.")
+	flags.StringVar(&c.Formatter.DetailHTMLTemplateFile, "detail-html-template",
+		c.Formatter.DetailHTMLTemplateFile, "HTML template file for the details of an alert.")
+	flags.StringVar(&c.Formatter.DetailTextTemplateFile, "detail-text-template",
+		c.Formatter.DetailTextTemplateFile, "Text template file for the details of an alert.")
+	flags.BoolVar(&c.ShowLabels, "show-labels", c.ShowLabels, "show labels of alerts messages.")
+	flags.StringVar(&c.ReloadToken, "reload-token", c.ReloadToken,
+		"Bearer token for the reload endpoint. The endpoint is disabled if empty.")
+	flags.StringVar(&c.WebhookAuth.BearerToken, "webhook-token", c.WebhookAuth.BearerToken,
+		"Bearer token required for webhooks. Webhooks are not authenticated if empty.")
+	flags.StringVar(&c.WebConfigFile, "web.config.file", c.WebConfigFile,
+		"Web configuration file for enabling TLS and basic authentication, in the format of the Prometheus exporter toolkit.")
+}
+
+// loadConfigFile loads the configuration from a YAML file.
+// Values that are not set in the file are left unchanged.
+func (c *Config) loadConfigFile(fileName string) error {
+	file, err := os.Open(fileName) //nolint:gosec // file inclusion is the point
+	if err != nil {
+		return fmt.Errorf("unable to open config file %q: %w", fileName, err)
+	}
+
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(c); err != nil {
+		return fmt.Errorf("unable to parse config file %q: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides the configuration with values from the environment.
+func (c *Config) loadEnv() {
+	setStringFromEnv(&c.Addr, "ADDR")
+	setStringFromEnv(&c.Homeserver, "HOMESERVER")
+	setStringFromEnv(&c.UserID, "USER_ID")
+	setStringFromEnv(&c.Token, "TOKEN")
+	setStringFromEnv(&c.AlertManagerURL, "ALERTMANAGER")
+	setStringFromEnv(&c.Rooms, "ROOMS")
+	setStringFromEnv(&c.StateFile, "STATE_FILE")
+	setStringFromEnv(&c.NotifyMode, "NOTIFY_MODE")
+	setStringFromEnv(&c.ReloadToken, "RELOAD_TOKEN")
+	setStringFromEnv(&c.WebConfigFile, "WEB_CONFIG_FILE")
+	setStringFromEnv(&c.WebhookAuth.BearerToken, "WEBHOOK_TOKEN")
+}
+
+// loadConfig loads the configuration from the defaults, the configuration file,
+// the command line flags and the environment, in order of increasing precedence.
+func loadConfig(configFile string) (*Config, error) {
+	config := defaultConfig()
+	configDir := ""
+
+	if configFile != "" {
+		if err := config.loadConfigFile(configFile); err != nil {
+			return nil, err
+		}
+
+		configDir = filepath.Dir(configFile)
+	}
+
+	// Parse the flags to override the configuration file, and the environment to override the flags
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	config.bindFlags(flags, new(string))
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+
+	config.loadEnv()
+
+	if config.AlertManagerHTTP == nil {
+		config.AlertManagerHTTP = alertmanager.DefaultHTTPConfig()
+	}
+
+	// Relative files in the HTTP configuration are relative to the configuration file
+	if err := config.AlertManagerHTTP.Validate(configDir); err != nil {
+		return nil, err //nolint:wrapcheck // error is descriptive
+	}
+
+	for name, am := range config.Alertmanagers {
+		if am == nil {
+			continue
+		}
+
+		if am.HTTPConfig == nil {
+			am.HTTPConfig = alertmanager.DefaultHTTPConfig()
+		}
+
+		if err := am.HTTPConfig.Validate(configDir); err != nil {
+			return nil, fmt.Errorf("invalid configuration for alertmanager %q: %w", name, err)
+		}
+	}
+
+	return config, nil
+}
+
+// clientConfig returns the configuration and default formatter for the bot client.
+// All configured formatting files are loaded.
+func (c *Config) clientConfig() (*bot2.ClientConfig, *bot2.Formatter, error) {
+	formatter, err := c.Formatter.formatter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := &bot2.ClientConfig{
+		Homeserver:              c.Homeserver,
+		UserID:                  c.UserID,
+		Token:                   c.Token,
+		MessageType:             c.MessageType,
+		Rooms:                   c.Rooms,
+		AlertManagerURL:         c.AlertManagerURL,
+		AlertManagerHTTPConfig:  c.AlertManagerHTTP,
+		Alertmanagers:           make(map[string]*bot2.AlertmanagerConfig, len(c.Alertmanagers)),
+		DefaultAlertmanager:     c.DefaultAlertmanager,
+		StateFile:               c.StateFile,
+		SilenceReaction:         c.SilenceReaction,
+		SilenceDuration:         c.SilenceDuration,
+		SilenceConfirmThreshold: c.SilenceConfirm,
+		AckDuration:             c.AckDuration,
+		ShowLabels:              c.ShowLabels,
+		NotifyMode:              bot2.NotifyMode(c.NotifyMode),
+		QueueSize:               c.QueueSize,
+		RetryMaxAge:             c.RetryMaxAge,
+		DedupWindow:             c.DedupWindow,
+		WebhookAuth:             c.WebhookAuth.webhookAuth(),
+		Confirm:                 &bot2.ConfirmConfig{Timeout: c.Confirm.Timeout, Commands: c.Confirm.Commands},
+		RoomConfigs:             make(map[string]*bot2.RoomConfig, len(c.RoomConfigs)),
+		Routes:                  make([]*bot2.Route, 0, len(c.Routes)),
+	}
+
+	for name, am := range c.Alertmanagers {
+		if am == nil {
+			am = new(AlertmanagerConfig)
+		}
+
+		config.Alertmanagers[name] = &bot2.AlertmanagerConfig{
+			URLs:       am.URLs,
+			HTTPConfig: am.HTTPConfig,
+		}
+
+		if am.URL != "" {
+			config.Alertmanagers[name].URLs = append([]string{am.URL}, am.URLs...)
+		}
+	}
+
+	for _, route := range c.Routes {
+		if route == nil {
+			route = new(RouteConfig)
+		}
+
+		config.Routes = append(config.Routes, &bot2.Route{
+			Name:        route.Name,
+			Rooms:       route.Rooms,
+			Matchers:    route.Matchers,
+			Continue:    route.Continue,
+			WebhookAuth: route.WebhookAuth.webhookAuth(),
+		})
+	}
+
+	for id, room := range c.RoomConfigs {
+		if room == nil {
+			room = new(RoomConfig)
+		}
+
+		config.RoomConfigs[id] = &bot2.RoomConfig{
+			MessageType:  room.MessageType,
+			ShowLabels:   room.ShowLabels,
+			NotifyMode:   bot2.NotifyMode(room.NotifyMode),
+			Commands:     room.Commands,
+			WebhookAuth:  room.WebhookAuth.webhookAuth(),
+			Alertmanager: room.Alertmanager,
+		}
+
+		if room.Formatter != (FormatterConfig{}) {
+			config.RoomConfigs[id].Formatter, err = room.Formatter.merge(&c.Formatter).formatter()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid configuration for room %q: %w", id, err)
+			}
+		}
+	}
+
+	return config, formatter, nil
+}
+
+// webhookAuth returns the webhook credentials for the bot client.
+func (a *AuthConfig) webhookAuth() *bot2.WebhookAuth {
+	return &bot2.WebhookAuth{
+		BearerToken: a.BearerToken,
+		Username:    a.BasicAuth.Username,
+		Password:    a.BasicAuth.Password,
+	}
+}
+
+// merge returns a copy of the formatter configuration with unset values taken from the given defaults.
+func (f FormatterConfig) merge(defaults *FormatterConfig) *FormatterConfig {
+	if f.IconFile == "" {
+		f.IconFile = defaults.IconFile
+	}
+
+	if f.ColorFile == "" {
+		f.ColorFile = defaults.ColorFile
+	}
+
+	if f.HTMLTemplateFile == "" {
+		f.HTMLTemplateFile = defaults.HTMLTemplateFile
+	}
+
+	if f.TextTemplateFile == "" {
+		f.TextTemplateFile = defaults.TextTemplateFile
+	}
+
+	if f.ListHTMLTemplateFile == "" {
+		f.ListHTMLTemplateFile = defaults.ListHTMLTemplateFile
+	}
+
+	if f.ListTextTemplateFile == "" {
+		f.ListTextTemplateFile = defaults.ListTextTemplateFile
+	}
+
+	if f.DetailHTMLTemplateFile == "" {
+		f.DetailHTMLTemplateFile = defaults.DetailHTMLTemplateFile
+	}
+
+	if f.DetailTextTemplateFile == "" {
+		f.DetailTextTemplateFile = defaults.DetailTextTemplateFile
+	}
+
+	return &f
+}
+
+// formatter creates a formatter from the configured files.
+func (f *FormatterConfig) formatter() (*bot2.Formatter, error) {
+	return formatter(f)
+}
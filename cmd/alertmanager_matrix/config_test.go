@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+
+	err := os.WriteFile(configFile, []byte("addr: :4052\nmessage_type: m.text\nnotify_mode: edit\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Error writing configuration file: %s", err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	os.Args = []string{"alertmanager_matrix", "-config", configFile, "-message-type", "m.notice", "-notify-mode", "message"}
+
+	t.Setenv("NOTIFY_MODE", "thread")
+
+	config, err := loadConfig(configFile)
+	if err != nil {
+		t.Fatalf("Error loading configuration: %s", err)
+	}
+
+	// Defaults are overridden by the file, the file by flags, and flags by the environment
+	expected := map[string][2]string{
+		"homeserver":   {defaultConfig().Homeserver, config.Homeserver},
+		"addr":         {":4052", config.Addr},
+		"message_type": {"m.notice", config.MessageType},
+		"notify_mode":  {"thread", config.NotifyMode},
+	}
+
+	for name, values := range expected {
+		if values[0] != values[1] {
+			t.Errorf("Expected %s to be %q, got %q", name, values[0], values[1])
+		}
+	}
+
+	if err = os.WriteFile(configFile, []byte("unknown: true\n"), 0o600); err != nil {
+		t.Fatalf("Error writing configuration file: %s", err)
+	}
+
+	if _, err = loadConfig(configFile); err == nil {
+		t.Errorf("Expected an error for an invalid configuration file")
+	}
+}
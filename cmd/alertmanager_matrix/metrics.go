@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// otherRoom is the value of the room label of metrics for rooms that are not configured.
+const otherRoom = "other"
+
+// Prometheus metrics.
+var (
+	webhooksTotal = promauto.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Name:      "webhooks_total",
+		Help: "Number of webhooks received by room, receiver and HTTP status code. " +
+			"Rooms that are not configured are counted as `" + otherRoom + "`.",
+	}, []string{"room", "receiver", "status"})
+
+	webhookAlerts = promauto.NewHistogram(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Name:      "webhook_alerts",
+		Help:      "Number of alerts per received webhook.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+	})
+)
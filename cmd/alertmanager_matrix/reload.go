@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	bot2 "github.com/silkeh/alertmanager_matrix/pkg/bot"
+)
+
+// reloader reloads the configuration of a client.
+type reloader struct {
+	mu         sync.Mutex
+	client     *bot2.Client
+	config     *Config // Configuration the service was started with.
+	configFile string
+	token      string // Bearer token for reloading over HTTP.
+}
+
+// Reload reloads the configuration and all files it refers to.
+// The running configuration is not changed if the new configuration is invalid.
+func (r *reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, err := loadConfig(r.configFile)
+	if err != nil {
+		return err
+	}
+
+	clientConfig, formatter, err := config.clientConfig()
+	if err != nil {
+		return err
+	}
+
+	if err = r.client.Reload(clientConfig, formatter); err != nil {
+		return fmt.Errorf("unable to apply configuration: %w", err)
+	}
+
+	if changed := restartSettings(r.config, config); len(changed) > 0 {
+		log.Printf("Warning: changes to %s are not applied until restart", strings.Join(changed, ", "))
+	}
+
+	return nil
+}
+
+// reloadedSettings contains the names of the settings that are applied when the configuration is reloaded,
+// in addition to the formatting files.
+var reloadedSettings = map[string]bool{ //nolint:gochecknoglobals
+	"show_labels":  true,
+	"notify_mode":  true,
+	"webhook_auth": true,
+	"rooms":        true,
+	"routes":       true,
+}
+
+// restartSettings returns the names of the settings that differ between the configurations,
+// and can only be applied by a restart.
+func restartSettings(running, reloaded *Config) []string {
+	var changed []string
+
+	runningValue, reloadedValue := reflect.ValueOf(running).Elem(), reflect.ValueOf(reloaded).Elem()
+
+	for i := 0; i < runningValue.NumField(); i++ {
+		// Inline settings contain the formatting files
+		name := strings.Split(runningValue.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || reloadedSettings[name] {
+			continue
+		}
+
+		if !reflect.DeepEqual(runningValue.Field(i).Interface(), reloadedValue.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
+
+// handleSignals reloads the configuration when a SIGHUP is received.
+func (r *reloader) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		r.reload()
+	}
+}
+
+// ServeHTTP reloads the configuration for authenticated requests.
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	expected := "Bearer " + r.token
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(expected)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// reload reloads the configuration and logs the result.
+func (r *reloader) reload() error {
+	log.Print("Reloading configuration")
+
+	err := r.Reload()
+	if err != nil {
+		log.Printf("Error reloading configuration: %s", err)
+	} else {
+		log.Print("Configuration reloaded")
+	}
+
+	return err
+}
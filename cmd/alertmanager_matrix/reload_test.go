@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestartSettings(t *testing.T) {
+	running := defaultConfig()
+	running.Alertmanagers = map[string]*AlertmanagerConfig{"prod": {URL: "http://prod:9093"}}
+	running.Confirm = ConfirmConfig{Commands: map[string]int{"silence del": 1}}
+
+	tests := []struct {
+		name     string
+		modify   func(c *Config)
+		expected []string
+	}{
+		{name: "unchanged", modify: func(c *Config) {}},
+		{
+			name: "reloaded settings",
+			modify: func(c *Config) {
+				c.ShowLabels = true
+				c.NotifyMode = "thread"
+				c.WebhookAuth = AuthConfig{BearerToken: "token"}
+				c.Formatter.HTMLTemplateFile = "alert.html.tmpl"
+				c.RoomConfigs = map[string]*RoomConfig{"!a:example.com": {MessageType: "m.text"}}
+				c.Routes = []*RouteConfig{{Rooms: []string{"!a:example.com"}}}
+			},
+		},
+		{
+			name: "restart settings",
+			modify: func(c *Config) {
+				c.Addr = ":4052"
+				c.Alertmanagers = map[string]*AlertmanagerConfig{"prod": {URL: "http://other:9093"}}
+				c.MessageType = "m.text"
+				c.SilenceReaction = ""
+				c.AckDuration = "2h"
+				c.ReloadToken = "token"
+				c.Confirm = ConfirmConfig{Commands: map[string]int{"silence del": 2}}
+			},
+			expected: []string{
+				"addr", "alertmanagers", "message_type", "silence_reaction", "ack_duration", "reload_token", "confirm",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			reloaded := *running
+			test.modify(&reloaded)
+
+			if changed := restartSettings(running, &reloaded); !reflect.DeepEqual(changed, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, changed)
+			}
+		})
+	}
+}
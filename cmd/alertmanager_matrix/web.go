@@ -0,0 +1,18 @@
+package main
+
+import (
+	"net/http"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+// listenAndServe starts the server with the TLS and basic authentication configuration
+// from the web configuration file, if given.
+// The web configuration file uses the format of the Prometheus exporter toolkit,
+// and is reloaded for every new connection.
+func listenAndServe(server *http.Server, webConfigFile string) error {
+	logger := kitlog.NewLogfmtLogger(kitlog.StdlibWriter{})
+
+	return web.ListenAndServe(server, webConfigFile, logger) //nolint:wrapcheck // errors are returned as-is
+}
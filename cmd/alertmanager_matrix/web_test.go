@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestListenAndServe(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Error hashing password: %s", err)
+	}
+
+	dir := t.TempDir()
+	validFile := filepath.Join(dir, "web.yml")
+	invalidFile := filepath.Join(dir, "invalid.yml")
+
+	if err = os.WriteFile(validFile, []byte(fmt.Sprintf("basic_auth_users:\n  user: %s\n", hash)), 0o600); err != nil {
+		t.Fatalf("Error writing web configuration file: %s", err)
+	}
+
+	if err = os.WriteFile(invalidFile, []byte("tls_server_config:\n  cert_file: missing.crt\n"), 0o600); err != nil {
+		t.Fatalf("Error writing web configuration file: %s", err)
+	}
+
+	if err = listenAndServe(&http.Server{Addr: "127.0.0.1:0"}, invalidFile); err == nil {
+		t.Errorf("Expected an error for an invalid web configuration file")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error finding a free port: %s", err)
+	}
+
+	addr := listener.Addr().String()
+	listener.Close()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: time.Second}
+	errs := make(chan error, 1)
+
+	go func() { errs <- listenAndServe(server, validFile) }()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		password string
+		expected int
+	}{
+		{name: "no credentials", expected: http.StatusUnauthorized},
+		{name: "wrong password", password: "wrong", expected: http.StatusUnauthorized},
+		{name: "valid password", password: "secret", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %s", err)
+		}
+
+		if test.password != "" {
+			req.SetBasicAuth("user", test.password)
+		}
+
+		resp, err := doWhenListening(req, errs)
+		if err != nil {
+			t.Fatalf("Error doing request with %s: %s", test.name, err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != test.expected {
+			t.Errorf("Expected status %d with %s, got %d", test.expected, test.name, resp.StatusCode)
+		}
+	}
+}
+
+// doWhenListening retries the request until the server is listening,
+// or returns the error of the server if it stopped.
+func doWhenListening(req *http.Request, errs <-chan error) (*http.Response, error) {
+	for i := 0; ; i++ {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil || i == 50 {
+			return resp, err
+		}
+
+		select {
+		case err := <-errs:
+			return nil, fmt.Errorf("server stopped: %w", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// AlertAPI provides access to the alerts of an Alertmanager.
+type AlertAPI struct {
+	client *Client
+}
+
+// AlertFilter determines which alerts are returned by the Alertmanager.
+type AlertFilter struct {
+	Matchers    []string // Label matchers, eg: `job="test"` (optional).
+	Receiver    string   // Regular expression matching receivers (optional).
+	Silenced    bool     // Include silenced alerts.
+	Inhibited   bool     // Include inhibited alerts.
+	Active      bool     // Include active alerts.
+	Unprocessed bool     // Include unprocessed alerts.
+}
+
+// query returns the query parameters for the filter.
+func (f *AlertFilter) query() url.Values {
+	query := url.Values{
+		"silenced":    {strconv.FormatBool(f.Silenced)},
+		"inhibited":   {strconv.FormatBool(f.Inhibited)},
+		"active":      {strconv.FormatBool(f.Active)},
+		"unprocessed": {strconv.FormatBool(f.Unprocessed)},
+	}
+
+	if f.Receiver != "" {
+		query.Set("receiver", f.Receiver)
+	}
+
+	for _, m := range f.Matchers {
+		query.Add("filter", m)
+	}
+
+	return query
+}
+
+// gettableAlert represents an alert returned by the API.
+type gettableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+	Receivers    []*Receiver       `json:"receivers"`
+	Status       struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+}
+
+// alert converts the API alert to an alert.
+func (a *gettableAlert) alert() *Alert {
+	alert := &Alert{
+		Status:       a.Status.State,
+		Labels:       a.Labels,
+		Annotations:  a.Annotations,
+		StartsAt:     a.StartsAt,
+		EndsAt:       a.EndsAt,
+		GeneratorURL: a.GeneratorURL,
+		Fingerprint:  a.Fingerprint,
+		SilencedBy:   a.Status.SilencedBy,
+		InhibitedBy:  a.Status.InhibitedBy,
+		Receivers:    make([]string, len(a.Receivers)),
+	}
+
+	for i, r := range a.Receivers {
+		alert.Receivers[i] = r.Name
+	}
+
+	return alert
+}
+
+// AlertGroup represents a group of alerts for a receiver.
+type AlertGroup struct {
+	Labels   map[string]string
+	Receiver string
+	Alerts   []*Alert
+}
+
+// List returns the alerts matching the filter.
+func (api *AlertAPI) List(ctx context.Context, filter *AlertFilter) ([]*Alert, error) {
+	var resp []*gettableAlert
+
+	if err := api.client.do(ctx, http.MethodGet, "/alerts", filter.query(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]*Alert, len(resp))
+	for i, a := range resp {
+		alerts[i] = a.alert()
+	}
+
+	return alerts, nil
+}
+
+// Groups returns the alerts matching the filter, grouped by the routes of the Alertmanager.
+func (api *AlertAPI) Groups(ctx context.Context, filter *AlertFilter) ([]*AlertGroup, error) {
+	var resp []struct {
+		Labels   map[string]string `json:"labels"`
+		Receiver *Receiver         `json:"receiver"`
+		Alerts   []*gettableAlert  `json:"alerts"`
+	}
+
+	// Unprocessed alerts are not part of any group
+	query := filter.query()
+	query.Del("unprocessed")
+
+	if err := api.client.do(ctx, http.MethodGet, "/alerts/groups", query, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	groups := make([]*AlertGroup, len(resp))
+
+	for i, g := range resp {
+		groups[i] = &AlertGroup{
+			Labels: g.Labels,
+			Alerts: make([]*Alert, len(g.Alerts)),
+		}
+
+		if g.Receiver != nil {
+			groups[i].Receiver = g.Receiver.Name
+		}
+
+		for j, a := range g.Alerts {
+			groups[i].Alerts[j] = a.alert()
+		}
+	}
+
+	return groups, nil
+}
+
+// SilenceAPI provides access to the silences of an Alertmanager.
+type SilenceAPI struct {
+	client *Client
+}
+
+// postableSilence represents a silence that is created or updated using the API.
+type postableSilence struct {
+	ID        string          `json:"id,omitempty"`
+	Matchers  labels.Matchers `json:"matchers"`
+	StartsAt  time.Time       `json:"startsAt"`
+	EndsAt    time.Time       `json:"endsAt"`
+	CreatedBy string          `json:"createdBy"`
+	Comment   string          `json:"comment"`
+}
+
+// Get returns the silence with the given ID.
+func (api *SilenceAPI) Get(ctx context.Context, id string) (*types.Silence, error) {
+	silence := new(types.Silence)
+
+	if err := api.client.do(ctx, http.MethodGet, "/silence/"+url.PathEscape(id), nil, nil, silence); err != nil {
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+// Set creates a silence, or updates it if the ID is set, and returns the ID of the silence.
+func (api *SilenceAPI) Set(ctx context.Context, silence types.Silence) (string, error) {
+	var resp struct {
+		SilenceID string `json:"silenceID"`
+	}
+
+	body := &postableSilence{
+		ID:        silence.ID,
+		Matchers:  silence.Matchers,
+		StartsAt:  silence.StartsAt,
+		EndsAt:    silence.EndsAt,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+	}
+
+	if err := api.client.do(ctx, http.MethodPost, "/silences", nil, body, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.SilenceID, nil
+}
+
+// Expire expires the silence with the given ID.
+func (api *SilenceAPI) Expire(ctx context.Context, id string) error {
+	return api.client.do(ctx, http.MethodDelete, "/silence/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// List returns the silences matching the given matchers, eg: `job="test"`.
+func (api *SilenceAPI) List(ctx context.Context, matchers ...string) ([]*types.Silence, error) {
+	var silences []*types.Silence
+
+	query := url.Values{}
+	for _, m := range matchers {
+		query.Add("filter", m)
+	}
+
+	if err := api.client.do(ctx, http.MethodGet, "/silences", query, nil, &silences); err != nil {
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+// StatusAPI provides access to the status of an Alertmanager.
+type StatusAPI struct {
+	client *Client
+}
+
+// Status represents the status of an Alertmanager.
+type Status struct {
+	Cluster struct {
+		Name   string  `json:"name"`
+		Status string  `json:"status"`
+		Peers  []*Peer `json:"peers"`
+	} `json:"cluster"`
+	VersionInfo struct {
+		Version   string `json:"version"`
+		Revision  string `json:"revision"`
+		Branch    string `json:"branch"`
+		BuildUser string `json:"buildUser"`
+		BuildDate string `json:"buildDate"`
+		GoVersion string `json:"goVersion"`
+	} `json:"versionInfo"`
+	Config struct {
+		Original string `json:"original"`
+	} `json:"config"`
+	Uptime time.Time `json:"uptime"`
+}
+
+// Peer represents a peer in an Alertmanager cluster.
+type Peer struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// Get returns the status of the Alertmanager.
+func (api *StatusAPI) Get(ctx context.Context) (*Status, error) {
+	status := new(Status)
+
+	if err := api.client.do(ctx, http.MethodGet, "/status", nil, nil, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// ReceiverAPI provides access to the receivers of an Alertmanager.
+type ReceiverAPI struct {
+	client *Client
+}
+
+// Receiver represents an Alertmanager receiver.
+type Receiver struct {
+	Name string `json:"name"`
+}
+
+// List returns all receivers.
+func (api *ReceiverAPI) List(ctx context.Context) ([]*Receiver, error) {
+	var receivers []*Receiver
+
+	if err := api.client.do(ctx, http.MethodGet, "/receivers", nil, nil, &receivers); err != nil {
+		return nil, err
+	}
+
+	return receivers, nil
+}
@@ -2,61 +2,203 @@
 package alertmanager
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// apiPath is the path of the Alertmanager API.
+const apiPath = "/api/v2"
 
-	alertmanager "github.com/prometheus/alertmanager/client"
-	"github.com/prometheus/client_golang/api"
+// maxErrorLength is the maximum length of an API error message.
+const maxErrorLength = 1024
+
+var (
+	errAPI        = errors.New("alertmanager API error")
+	errNoReplicas = errors.New("no alertmanager URL given")
 )
 
+// apiError represents an error response of the Alertmanager API.
+type apiError struct {
+	code    int
+	status  string
+	message string
+}
+
+// Error returns the error message.
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", errAPI, e.status, e.message)
+}
+
+// Unwrap returns the generic API error.
+func (e *apiError) Unwrap() error {
+	return errAPI
+}
+
 // Client represents a multi-functional Alertmanager API client.
+// It uses version 2 of the Alertmanager API.
+// Requests fail over to other replicas of an Alertmanager cluster when a replica is unavailable.
 type Client struct {
-	Alert   alertmanager.AlertAPI
-	Silence alertmanager.SilenceAPI
-	Status  alertmanager.StatusAPI
+	Alert    *AlertAPI
+	Silence  *SilenceAPI
+	Status   *StatusAPI
+	Receiver *ReceiverAPI
+
+	replicas []*replica
+	client   *http.Client
+	mu       sync.Mutex
 }
 
-// NewClient creates an Alertmanager API client.
-func NewClient(url string) (*Client, error) {
-	c, err := api.NewClient(api.Config{Address: url})
+// NewClient creates an Alertmanager API client for the given replicas of an Alertmanager cluster.
+// Replicas are used in the given order, preferring replicas that are healthy.
+// The default HTTP configuration is used if the given configuration is nil.
+func NewClient(addresses []string, httpConfig *HTTPConfig) (*Client, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("error creating alertmanager client: %w", errNoReplicas)
+	}
+
+	replicas := make([]*replica, len(addresses))
+
+	for i, address := range addresses {
+		u, err := url.Parse(strings.TrimSpace(address))
+		if err != nil {
+			return nil, fmt.Errorf("error creating alertmanager client: %w", err)
+		}
+
+		u.Path = strings.TrimRight(u.Path, "/")
+		replicas[i] = &replica{url: u, healthy: true}
+	}
+
+	if httpConfig == nil {
+		httpConfig = DefaultHTTPConfig()
+	}
+
+	httpClient, err := httpConfig.httpClient()
 	if err != nil {
 		return nil, fmt.Errorf("error creating alertmanager client: %w", err)
 	}
 
-	client := &Client{
-		Alert:   alertmanager.NewAlertAPI(c),
-		Silence: alertmanager.NewSilenceAPI(c),
-		Status:  alertmanager.NewStatusAPI(c),
-	}
+	httpClient.Transport = &instrumentedRoundTripper{next: httpClient.Transport}
+
+	client := &Client{replicas: replicas, client: httpClient}
+
+	client.Alert = &AlertAPI{client: client}
+	client.Silence = &SilenceAPI{client: client}
+	client.Status = &StatusAPI{client: client}
+	client.Receiver = &ReceiverAPI{client: client}
 
 	return client, nil
 }
 
-// GetAlerts retrieves all silenced or non-silenced alerts.
-func (am *Client) GetAlerts(silenced bool) ([]*Alert, error) {
-	alerts, err := am.Alert.List(context.Background(), "", "",
-		silenced, false, true, true)
-	if err != nil {
-		return nil, fmt.Errorf("error retrieving commands from alertmanager: %w", err)
+// do executes an API request with the given query and JSON body,
+// and decodes the JSON response into the result, if not nil.
+// The request is sent to the next replica if a replica is unavailable,
+// see failover for the conditions.
+func (am *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	var (
+		reqBody []byte
+		err     error
+	)
+
+	if body != nil {
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
 	}
 
-	// Map alerts to compatible type
-	as := make([]*Alert, len(alerts))
-	for i, a := range alerts {
-		as[i] = &Alert{
-			ExtendedAlert: a,
-			Status:        string(a.Status.State),
+	for _, r := range am.orderedReplicas() {
+		err = am.doReplica(ctx, r, method, path, query, reqBody, result)
+		am.setHealth(ctx, r, err)
+
+		if !failover(ctx, method, err) {
+			return err
 		}
 	}
 
-	return as, nil
+	return err
+}
+
+// doReplica executes an API request on a single replica.
+func (am *Client) doReplica(ctx context.Context, r *replica,
+	method, path string, query url.Values, body []byte, result interface{},
+) error {
+	u := *r.url
+	u.Path += apiPath + path
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := am.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request to alertmanager: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+
+		return &apiError{code: resp.StatusCode, status: resp.Status, message: strings.TrimSpace(string(msg))}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding alertmanager response: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlerts retrieves all silenced or non-silenced alerts.
+func (am *Client) GetAlerts(silenced bool) ([]*Alert, error) {
+	alerts, err := am.Alert.List(context.Background(), &AlertFilter{
+		Silenced:    silenced,
+		Active:      true,
+		Unprocessed: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving alerts from alertmanager: %w", err)
+	}
+
+	return alerts, nil
 }
 
 // GetAlert retrieves an alert with a given ID.
+// Silenced, inhibited and unprocessed alerts are included.
 func (am *Client) GetAlert(id string) (alert *Alert, err error) {
-	alerts, err := am.GetAlerts(true)
+	alerts, err := am.Alert.List(context.Background(), &AlertFilter{
+		Silenced:    true,
+		Inhibited:   true,
+		Active:      true,
+		Unprocessed: true,
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error retrieving alerts from alertmanager: %w", err)
 	}
 
 	for _, a := range alerts {
@@ -0,0 +1,90 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// defaultTimeout is the default timeout of requests to the Alertmanager API.
+const defaultTimeout = model.Duration(time.Minute)
+
+// HTTPConfig contains the configuration of the HTTP client used for the Alertmanager API.
+type HTTPConfig struct {
+	// Client contains the authentication, TLS and proxy configuration,
+	// in the format used by Prometheus.
+	Client config.HTTPClientConfig `yaml:",inline"`
+
+	// Headers contains additional headers for all requests.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout of requests, including reading the response.
+	// Requests only time out when their context expires if set to zero.
+	Timeout model.Duration `yaml:"timeout"`
+}
+
+// DefaultHTTPConfig returns the default HTTP configuration.
+func DefaultHTTPConfig() *HTTPConfig {
+	return &HTTPConfig{Client: config.DefaultHTTPClientConfig, Timeout: defaultTimeout}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+// Unset values are set to their default.
+func (c *HTTPConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain HTTPConfig
+
+	*c = *DefaultHTTPConfig()
+
+	return unmarshal((*plain)(c))
+}
+
+// Validate validates the HTTP configuration.
+// Relative file paths are interpreted relative to the given directory.
+func (c *HTTPConfig) Validate(dir string) error {
+	if dir != "" {
+		c.Client.SetDirectory(filepath.Clean(dir))
+	}
+
+	if err := c.Client.Validate(); err != nil {
+		return fmt.Errorf("invalid Alertmanager HTTP configuration: %w", err)
+	}
+
+	return nil
+}
+
+// httpClient returns an HTTP client for the configuration.
+func (c *HTTPConfig) httpClient() (*http.Client, error) {
+	client, err := config.NewClientFromConfig(c.Client, "alertmanager")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Alertmanager HTTP configuration: %w", err)
+	}
+
+	client.Timeout = time.Duration(c.Timeout)
+
+	if len(c.Headers) > 0 {
+		client.Transport = &headerRoundTripper{headers: c.Headers, next: client.Transport}
+	}
+
+	return client, nil
+}
+
+// headerRoundTripper is an http.RoundTripper that adds headers to requests.
+type headerRoundTripper struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+// RoundTrip executes an HTTP request with the configured headers.
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for k, v := range rt.headers {
+		req.Header.Set(k, v)
+	}
+
+	return rt.next.RoundTrip(req) //nolint:wrapcheck // errors are wrapped by the API client
+}
@@ -0,0 +1,40 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestHTTPConfigTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected time.Duration
+	}{
+		{name: "default", yaml: `headers: {X-Scope-OrgID: test}`, expected: time.Minute},
+		{name: "configured", yaml: `timeout: 10s`, expected: 10 * time.Second},
+		{name: "disabled", yaml: `timeout: 0s`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			config := new(HTTPConfig)
+			if err := yaml.Unmarshal([]byte(test.yaml), config); err != nil {
+				t.Fatalf("Error parsing configuration: %s", err)
+			}
+
+			client, err := config.httpClient()
+			if err != nil {
+				t.Fatalf("Error creating client: %s", err)
+			}
+
+			if client.Timeout != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, client.Timeout)
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package alertmanager
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	alertmanager "github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 )
 
 const (
@@ -19,34 +23,93 @@ const (
 )
 
 // Message represents a message received from Alertmanager via webhook.
+// It contains all fields of version 4 of the webhook payload.
 type Message struct {
-	Version           string
-	GroupKey          string
-	Status            string
-	Receiver          string
-	GroupLabels       map[string]string
-	CommonLabels      map[string]string
-	CommonAnnotations map[string]string
-	ExternalURL       string
-	Alerts            []*Alert
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []*Alert          `json:"alerts"`
 }
 
-// Alert represents an Alert received from Alertmanager via webhook.
-// It is extended with the `status` attribute, and various convenient functions for formatting.
+// Alert represents an alert received from Alertmanager via webhook or retrieved using the API.
+// It contains various convenient functions for formatting.
 type Alert struct {
-	*alertmanager.ExtendedAlert
-	Status string `json:"status"`
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+
+	// Fields that are only set for alerts retrieved using the API.
+	SilencedBy  []string `json:"-"` // IDs of the silences that silence the alert.
+	InhibitedBy []string `json:"-"` // Fingerprints of the alerts that inhibit the alert.
+	Receivers   []string `json:"-"` // Names of the receivers of the alert.
+
+	// AckedBy contains the user that acknowledged the alert, if any.
+	AckedBy string `json:"-"`
+
+	// Alertmanager contains the name of the Alertmanager the alert was retrieved from,
+	// if alerts from multiple Alertmanagers are shown.
+	Alertmanager string `json:"-"`
 }
 
 // AlertName returns the value of the `alertname` label.
 func (a *Alert) AlertName() string {
-	if v, ok := a.ExtendedAlert.Labels[alertNameLabel]; ok {
-		return string(v)
+	if v, ok := a.Labels[alertNameLabel]; ok {
+		return v
 	}
 
 	return ""
 }
 
+// ExtendedAlert returns the alert as the alert type of the Alertmanager API client.
+// It is provided for compatibility with templates and code that used the embedded type,
+// such as `.ExtendedAlert.Fingerprint` and `.ExtendedAlert.Status.SilencedBy`.
+func (a *Alert) ExtendedAlert() *alertmanager.ExtendedAlert {
+	return &alertmanager.ExtendedAlert{
+		Alert: alertmanager.Alert{
+			Labels:       labelSet(a.Labels),
+			Annotations:  labelSet(a.Annotations),
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		},
+		Status: types.AlertStatus{
+			State:       types.AlertState(a.Status),
+			SilencedBy:  a.SilencedBy,
+			InhibitedBy: a.InhibitedBy,
+		},
+		Receivers:   a.Receivers,
+		Fingerprint: a.Fingerprint,
+	}
+}
+
+// Alert returns the alert as the basic alert type of the Alertmanager API client.
+// It is provided for compatibility with templates and code that used the embedded type,
+// such as `.Alert.Labels`.
+func (a *Alert) Alert() *alertmanager.Alert {
+	return &a.ExtendedAlert().Alert
+}
+
+// labelSet converts a map of labels or annotations to a label set of the Alertmanager API client.
+func labelSet(m map[string]string) alertmanager.LabelSet {
+	lset := make(alertmanager.LabelSet, len(m))
+
+	for n, v := range m {
+		lset[alertmanager.LabelName(n)] = alertmanager.LabelValue(v)
+	}
+
+	return lset
+}
+
 // StatusString returns a string representing the status.
 // This is either `resolved`, `silenced`, the value of the `severity` label, or `alert`.
 func (a *Alert) StatusString() string {
@@ -58,8 +121,8 @@ func (a *Alert) StatusString() string {
 		return silencedStatus
 	}
 
-	if sev, ok := a.Alert.Labels[severityLabel]; ok {
-		return string(sev)
+	if sev, ok := a.Labels[severityLabel]; ok {
+		return sev
 	}
 
 	return alertStatus
@@ -69,12 +132,12 @@ func (a *Alert) StatusString() string {
 // the `resolved` annotation for `resolved` messages,
 // or an empty string if neither annotation is present.
 func (a *Alert) Summary() string {
-	if v, ok := a.Alert.Annotations[resolvedAnnotation]; ok && a.Status == resolvedStatus {
-		return string(v)
+	if v, ok := a.Annotations[resolvedAnnotation]; ok && a.Status == resolvedStatus {
+		return v
 	}
 
-	if v, ok := a.Alert.Annotations[summaryAnnotation]; ok {
-		return string(v)
+	if v, ok := a.Annotations[summaryAnnotation]; ok {
+		return v
 	}
 
 	return ""
@@ -82,11 +145,37 @@ func (a *Alert) Summary() string {
 
 // LabelString returns a formatted list of message labels in the form {key="value"}.
 func (a *Alert) LabelString() string {
-	labels := make([]string, 0, len(a.Alert.Labels))
+	labels := make([]string, 0, len(a.Labels))
 
-	for n, v := range a.Alert.Labels {
+	for n, v := range a.Labels {
 		labels = append(labels, fmt.Sprintf(`%s=%q`, n, v))
 	}
 
 	return "{" + strings.Join(labels, ",") + "}"
 }
+
+// Duration returns the time the alert has been firing,
+// or the time it was firing for if it is resolved.
+func (a *Alert) Duration() time.Duration {
+	if a.Status == resolvedStatus && !a.EndsAt.IsZero() {
+		return a.EndsAt.Sub(a.StartsAt).Round(time.Second)
+	}
+
+	return time.Since(a.StartsAt).Round(time.Second)
+}
+
+// LabelSet returns the labels of the alert as a label set.
+func (a *Alert) LabelSet() model.LabelSet {
+	lset := make(model.LabelSet, len(a.Labels))
+
+	for n, v := range a.Labels {
+		lset[model.LabelName(n)] = model.LabelValue(v)
+	}
+
+	return lset
+}
+
+// MatchedBy returns true if the labels of the alert match all given matchers.
+func (a *Alert) MatchedBy(matchers labels.Matchers) bool {
+	return matchers.Matches(a.LabelSet())
+}
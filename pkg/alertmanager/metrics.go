@@ -0,0 +1,65 @@
+package alertmanager
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics.
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "alertmanager",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of Alertmanager API requests by method and endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "alertmanager",
+		Name:      "request_errors_total",
+		Help:      "Number of failed Alertmanager API requests by method and endpoint.",
+	}, []string{"method", "endpoint"})
+)
+
+// instrumentedRoundTripper is an http.RoundTripper that records metrics for API requests.
+type instrumentedRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip executes an HTTP request and records its duration and result.
+func (rt *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := apiEndpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+
+	requestDuration.WithLabelValues(req.Method, endpoint).Observe(time.Since(start).Seconds())
+
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		requestErrors.WithLabelValues(req.Method, endpoint).Inc()
+	}
+
+	return resp, err //nolint:wrapcheck // errors are wrapped by the API client
+}
+
+// apiEndpoint returns the API endpoint for a request path.
+// Any path prefix is removed, and silence IDs are replaced by a placeholder.
+func apiEndpoint(path string) string {
+	if i := strings.Index(path, "/api/"); i >= 0 {
+		path = path[i:]
+	}
+
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if parts[i-1] == "silence" {
+			parts[i] = ":id"
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
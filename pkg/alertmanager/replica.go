@@ -0,0 +1,165 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// replica represents a single replica of an Alertmanager cluster.
+type replica struct {
+	url         *url.URL
+	healthy     bool
+	lastError   error
+	lastChecked time.Time
+}
+
+// ReplicaStatus contains the health of a replica of an Alertmanager cluster.
+type ReplicaStatus struct {
+	URL         string    // URL of the replica, without credentials.
+	Healthy     bool      // False if the replica was unreachable or returned a server error on the last request.
+	Error       error     // Error of the last request, if it failed.
+	LastChecked time.Time // Time of the last request, zero if the replica has not been used.
+	Status      *Status   // Status of the replica, only set by CheckReplicas.
+}
+
+// failover returns true if a request that failed with the given error should be sent to another replica.
+// This is the case for connection errors and server errors, but not for client errors.
+// Requests that modify data are only sent to another replica if the connection could not be established,
+// as the replica may have processed the request otherwise.
+func failover(ctx context.Context, method string, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	if method != http.MethodGet {
+		return dialError(err)
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.code >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+// unhealthy returns true if the given error indicates that a replica is unhealthy.
+// This is the case if the connection could not be established or the replica returned a server error.
+func unhealthy(err error) bool {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.code >= http.StatusInternalServerError
+	}
+
+	return dialError(err)
+}
+
+// dialError returns true if the given error is caused by a failure to establish a connection.
+func dialError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// orderedReplicas returns the replicas in the order in which they should be used:
+// healthy replicas first, in the configured order, followed by unhealthy replicas.
+func (am *Client) orderedReplicas() []*replica {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	replicas := make([]*replica, 0, len(am.replicas))
+
+	for _, r := range am.replicas {
+		if r.healthy {
+			replicas = append(replicas, r)
+		}
+	}
+
+	for _, r := range am.replicas {
+		if !r.healthy {
+			replicas = append(replicas, r)
+		}
+	}
+
+	return replicas
+}
+
+// setHealth updates the health of a replica after a request with the given result.
+// A replica is unhealthy if it is unreachable or returns server errors, see unhealthy.
+// The health is not changed if the request failed because the context expired.
+func (am *Client) setHealth(ctx context.Context, r *replica, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	healthy := !unhealthy(err)
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	r.healthy = healthy
+	r.lastError = nil
+	r.lastChecked = time.Now()
+
+	if !healthy {
+		r.lastError = err
+	}
+}
+
+// URL returns the URL of the preferred replica of the Alertmanager, without credentials.
+func (am *Client) URL() string {
+	u := *am.orderedReplicas()[0].url
+	u.User = nil
+
+	return u.String()
+}
+
+// Replicas returns the health of the replicas of the Alertmanager, in the configured order.
+// The health is based on the last request to each replica.
+func (am *Client) Replicas() []*ReplicaStatus {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	statuses := make([]*ReplicaStatus, len(am.replicas))
+
+	for i, r := range am.replicas {
+		statuses[i] = &ReplicaStatus{
+			URL:         r.url.Redacted(),
+			Healthy:     r.healthy,
+			Error:       r.lastError,
+			LastChecked: r.lastChecked,
+		}
+	}
+
+	return statuses
+}
+
+// CheckReplicas retrieves the status of every replica of the Alertmanager,
+// updates their health and returns it, in the configured order.
+func (am *Client) CheckReplicas(ctx context.Context) []*ReplicaStatus {
+	statuses := make([]*ReplicaStatus, len(am.replicas))
+
+	for i, r := range am.replicas {
+		status := new(Status)
+
+		err := am.doReplica(ctx, r, http.MethodGet, "/status", nil, nil, status)
+		am.setHealth(ctx, r, err)
+
+		statuses[i] = &ReplicaStatus{
+			URL:         r.url.Redacted(),
+			Healthy:     err == nil,
+			Error:       err,
+			LastChecked: time.Now(),
+		}
+
+		if err == nil {
+			statuses[i].Status = status
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,158 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFailover(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dialErr := fmt.Errorf("error sending request to alertmanager: %w",
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	readErr := fmt.Errorf("error sending request to alertmanager: %w",
+		&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		err      error
+		expected bool
+	}{
+		{name: "success", method: http.MethodGet},
+		{name: "read server error", method: http.MethodGet, err: &apiError{code: 503}, expected: true},
+		{name: "read client error", method: http.MethodGet, err: &apiError{code: 400}},
+		{name: "read dial error", method: http.MethodGet, err: dialErr, expected: true},
+		{name: "read connection error", method: http.MethodGet, err: readErr, expected: true},
+		{name: "read canceled", ctx: canceled, method: http.MethodGet, err: dialErr},
+		{name: "write server error", method: http.MethodPost, err: &apiError{code: 500}},
+		{name: "write client error", method: http.MethodPost, err: &apiError{code: 400}},
+		{name: "write dial error", method: http.MethodPost, err: dialErr, expected: true},
+		{name: "write connection error", method: http.MethodPost, err: readErr},
+		{name: "write other error", method: http.MethodPost, err: errors.New("test")},
+		{name: "write canceled", ctx: canceled, method: http.MethodPost, err: dialErr},
+		{name: "delete dial error", method: http.MethodDelete, err: dialErr, expected: true},
+		{name: "delete server error", method: http.MethodDelete, err: &apiError{code: 502}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			if failover(ctx, test.method, test.err) != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, !test.expected)
+			}
+		})
+	}
+}
+
+func TestSetHealth(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dialErr := fmt.Errorf("error sending request to alertmanager: %w",
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		initial  bool
+		err      error
+		expected bool
+	}{
+		{name: "success", expected: true},
+		{name: "recovered", initial: false, expected: true},
+		{name: "server error", initial: true, err: &apiError{code: 503}},
+		{name: "client error", initial: true, err: &apiError{code: 404}, expected: true},
+		{name: "dial error", initial: true, err: dialErr},
+		{name: "other error", initial: true, err: errors.New("test"), expected: true},
+		{name: "canceled healthy", ctx: canceled, initial: true, err: dialErr, expected: true},
+		{name: "canceled unhealthy", ctx: canceled, initial: false, err: context.Canceled},
+		{name: "deadline exceeded", ctx: canceled, initial: true, err: context.DeadlineExceeded, expected: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			r := &replica{healthy: test.initial}
+			client := &Client{replicas: []*replica{r}}
+
+			client.setHealth(ctx, r, test.err)
+
+			if r.healthy != test.expected {
+				t.Errorf("Expected healthy to be %v, got %v", test.expected, r.healthy)
+			}
+		})
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	var requests int32
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		first    string
+		requests int32
+	}{
+		{name: "read from failing replica", method: http.MethodGet, first: failing.URL, requests: 1},
+		{name: "read from unavailable replica", method: http.MethodGet, first: down.URL, requests: 1},
+		{name: "write to failing replica", method: http.MethodPost, first: failing.URL, requests: 0},
+		{name: "write to unavailable replica", method: http.MethodPost, first: down.URL, requests: 1},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+
+			client, err := NewClient([]string{test.first, healthy.URL}, nil)
+			if err != nil {
+				t.Fatalf("Error creating client: %s", err)
+			}
+
+			err = client.do(context.Background(), test.method, "/silences", nil, nil, nil)
+			if (err == nil) != (test.requests > 0) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if n := atomic.LoadInt32(&requests); n != test.requests {
+				t.Errorf("Expected %d request(s) to the healthy replica, got %d", test.requests, n)
+			}
+		})
+	}
+}
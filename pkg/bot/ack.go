@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+var errAckExtend = errors.New("unable to extend silences")
+
+// ackCommentPrefix is the prefix of the comment of silences that acknowledge alerts.
+const ackCommentPrefix = "[ack] "
+
+// ackCommentDuration separates the duration of an acknowledgement from the rest of the comment.
+const ackCommentDuration = " for "
+
+// Acknowledgement extension settings.
+const (
+	defaultAckDuration = "1h"            // Default duration of acknowledgements.
+	ackCheckInterval   = time.Minute     // Interval for checking acknowledgements.
+	ackExtendBefore    = 5 * time.Minute // Time before the end of an acknowledgement at which it is extended.
+	ackAnnotateTimeout = 5 * time.Second // Maximum duration of retrieving acknowledgements for notifications.
+)
+
+// ackCommand returns the `ack` command.
+func (c *Client) ackCommand() *bot.Command {
+	return &bot.Command{
+		Summary: "Acknowledge an alert.",
+		Description: "Acknowledge alerts using a `fingerprint` or `matcher` and an optional `duration`.\n\n" +
+			"This creates a silence that is extended automatically while the alerts are firing, for example:\n" +
+			"```\nack 04e45af092081699 30m\n```\n" +
+			"The Alertmanager can be selected using `--am=<name>`, or `--am=all` for all Alertmanagers.\n",
+		MessageHandler: c.handler("ack", func(sender string, args []string) (*bot.Message, error) {
+			am, args := alertmanagerArg(args)
+			if len(args) == 0 {
+				return insufficientArgs()
+			}
+
+			duration := c.ackDuration
+
+			if len(args) > 1 {
+				if _, err := parseDuration(args[len(args)-1]); err == nil {
+					duration = args[len(args)-1]
+					args = args[:len(args)-1]
+				}
+			}
+
+			md, err := c.Ack(sender, am, duration, strings.Join(args, " "))
+
+			return bot.NewMarkdownMessage(md), err
+		}),
+	}
+}
+
+// Ack acknowledges the alerts matching the given matchers or fingerprint in the given Alertmanager.
+// An error is returned if the alerts could not be acknowledged in one or more Alertmanagers.
+func (c *Client) Ack(author, am, durationStr, matchers string) (string, error) {
+	names, err := c.targetAlertmanagers(am)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	var errs []string
+
+	messages := make([]string, 0, len(names))
+
+	for _, name := range names {
+		id, err := c.newSilence(c.Alertmanagers[name], author,
+			ackCommentPrefix+"Acknowledged from Matrix"+ackCommentDuration+durationStr, durationStr, matchers, time.Now())
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			messages = append(messages, c.alertmanagerError(name, err))
+
+			continue
+		}
+
+		if c.multipleAlertmanagers() {
+			messages = append(messages, fmt.Sprintf("Acknowledged by %s in *%s* with silence ID *%s*", author, name, id))
+		} else {
+			messages = append(messages, fmt.Sprintf("Acknowledged by %s with silence ID *%s*", author, id))
+		}
+	}
+
+	return strings.Join(messages, "\n\n"), commandError(errs)
+}
+
+// ackSilences returns all active silences that acknowledge alerts.
+func (c *Client) ackSilences(ctx context.Context, am *alertmanager.Client) ([]*types.Silence, error) {
+	silences, err := am.Silence.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving silences from alertmanager: %w", err)
+	}
+
+	acks := make([]*types.Silence, 0, len(silences))
+
+	for _, s := range silences {
+		if s.Status.State == types.SilenceStateActive && strings.HasPrefix(s.Comment, ackCommentPrefix) {
+			acks = append(acks, s)
+		}
+	}
+
+	return acks, nil
+}
+
+// annotateAcks sets the user that acknowledged an alert for the given alerts.
+func (c *Client) annotateAcks(ctx context.Context, am *alertmanager.Client, alerts []*alertmanager.Alert) error {
+	silences, err := c.ackSilences(ctx, am)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range alerts {
+		for _, s := range silences {
+			if a.MatchedBy(s.Matchers) {
+				a.AckedBy = s.CreatedBy
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// extendAcks periodically extends acknowledgements of alerts that are still firing.
+func (c *Client) extendAcks() {
+	ticker := time.NewTicker(ackCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, name := range c.alertmanagerNames() {
+			if err := c.extendFiringAcks(c.Alertmanagers[name]); err != nil {
+				log.Printf("Error extending acknowledgements in %s: %s", name, err)
+			}
+		}
+	}
+}
+
+// extendFiringAcks extends acknowledgements that are about to end
+// if any of the acknowledged alerts are still firing.
+func (c *Client) extendFiringAcks(am *alertmanager.Client) error {
+	silences, err := c.ackSilences(context.Background(), am)
+	if err != nil || len(silences) == 0 {
+		return err
+	}
+
+	alerts, err := am.GetAlerts(true)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	for _, s := range silences {
+		if time.Until(s.EndsAt) > ackExtendBefore || !matchesAny(s, alerts) {
+			continue
+		}
+
+		duration, err := c.ackSilenceDuration(s)
+		if err == nil {
+			s.EndsAt = time.Now().Add(duration)
+			_, err = am.Silence.Set(context.Background(), *s)
+		}
+
+		// Other acknowledgements are still extended
+		if err != nil {
+			log.Printf("Error extending silence %s: %s", s.ID, err)
+
+			failed = append(failed, s.ID)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", errAckExtend, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// ackSilenceDuration returns the duration chosen for an acknowledgement, which is recorded in the comment.
+// The default acknowledgement duration is returned for acknowledgements without a recorded duration.
+func (c *Client) ackSilenceDuration(silence *types.Silence) (time.Duration, error) {
+	comment := strings.SplitN(silence.Comment, "\n", 2)[0]
+
+	if i := strings.LastIndex(comment, ackCommentDuration); i >= 0 {
+		if d, err := parseDuration(comment[i+len(ackCommentDuration):]); err == nil {
+			return d, nil
+		}
+	}
+
+	return parseDuration(c.ackDuration)
+}
+
+// matchesAny returns true if the silence matches any of the given alerts.
+func matchesAny(silence *types.Silence, alerts []*alertmanager.Alert) bool {
+	for _, a := range alerts {
+		if a.MatchedBy(silence.Matchers) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExtendFiringAcks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		extended []string
+	)
+
+	endsAt := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+
+	client := newTestClient(t, testHandlers{
+		"/api/v2/silences": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				// Acknowledgements that are about to end, the first of which cannot be extended
+				for i, id := range []string{"broken", "first", "second"} {
+					sep := ","
+					if i == 0 {
+						sep = "["
+					}
+
+					_, _ = fmt.Fprintf(w, `%s{"id":%q,"matchers":[{"name":"job","value":"a","isEqual":true}],`+
+						`"endsAt":%q,"comment":"[ack] Acknowledged from Matrix for 1h","status":{"state":"active"}}`,
+						sep, id, endsAt)
+				}
+
+				_, _ = w.Write([]byte(`]`))
+
+				return
+			}
+
+			var silence struct {
+				ID string `json:"id"`
+			}
+
+			_ = json.NewDecoder(r.Body).Decode(&silence)
+
+			if silence.ID == "broken" {
+				http.Error(w, "invalid silence", http.StatusBadRequest)
+
+				return
+			}
+
+			mu.Lock()
+			extended = append(extended, silence.ID)
+			mu.Unlock()
+
+			_, _ = fmt.Fprintf(w, `{"silenceID":%q}`, silence.ID)
+		},
+		"/api/v2/alerts": respond(`[{"labels":{"job":"a"},"status":{"state":"active"}}]`),
+	}, nil)
+
+	err := client.extendFiringAcks(client.Alertmanagers[DefaultAlertmanager])
+	if !errors.Is(err, errAckExtend) || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected an error for the broken acknowledgement, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if strings.Join(extended, ",") != "first,second" {
+		t.Errorf("Expected the other acknowledgements to be extended, got %q", extended)
+	}
+}
@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Alertmanager names with a special meaning.
+const (
+	DefaultAlertmanager = "default" // Name of the Alertmanager configured using ClientConfig.AlertManagerURL.
+	AllAlertmanagers    = "all"     // Name selecting all configured Alertmanagers.
+)
+
+// alertmanagerOption is the command option for selecting an Alertmanager.
+const alertmanagerOption = "--am"
+
+var (
+	errInvalidAlertmanager   = errors.New("invalid alertmanager configuration")
+	errUnknownAlertmanager   = errors.New("unknown alertmanager")
+	errNoAlertmanager        = errors.New("no alertmanager configured")
+	errAmbiguousAlertmanager = errors.New("multiple alertmanagers configured, select one using `--am=<name>` or `--am=all`")
+)
+
+// AlertmanagerConfig contains the configuration of a single Alertmanager instance or cluster.
+type AlertmanagerConfig struct {
+	URLs       []string                 // URLs to the Alertmanager API of each replica, in order of preference.
+	HTTPConfig *alertmanager.HTTPConfig // HTTP configuration for the Alertmanager API (optional).
+}
+
+// newAlertmanagers creates the Alertmanager clients for the client configuration.
+// A single Alertmanager named `default` is created from AlertManagerURL if no Alertmanagers are configured.
+func newAlertmanagers(config *ClientConfig) (map[string]*alertmanager.Client, error) {
+	configs := config.Alertmanagers
+	if len(configs) == 0 {
+		configs = map[string]*AlertmanagerConfig{
+			DefaultAlertmanager: {
+				URLs:       strings.Split(config.AlertManagerURL, ","),
+				HTTPConfig: config.AlertManagerHTTPConfig,
+			},
+		}
+	}
+
+	clients := make(map[string]*alertmanager.Client, len(configs))
+
+	for name, amConfig := range configs {
+		if name == "" || name == AllAlertmanagers || strings.ContainsAny(name, " /") {
+			return nil, fmt.Errorf("%w: invalid name %q", errInvalidAlertmanager, name)
+		}
+
+		if amConfig == nil || len(amConfig.URLs) == 0 || contains(amConfig.URLs, "") {
+			return nil, fmt.Errorf("%w: no URL configured for %q", errInvalidAlertmanager, name)
+		}
+
+		client, err := alertmanager.NewClient(amConfig.URLs, amConfig.HTTPConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for alertmanager %q: %w", name, err)
+		}
+
+		clients[name] = client
+	}
+
+	return clients, nil
+}
+
+// validateAlertmanager returns an error if the given name is not a configured Alertmanager.
+// An empty name and `all` are accepted.
+func (c *Client) validateAlertmanager(name string) error {
+	if name == "" || name == AllAlertmanagers {
+		return nil
+	}
+
+	if _, ok := c.Alertmanagers[name]; !ok {
+		return fmt.Errorf("%w: %q", errUnknownAlertmanager, name)
+	}
+
+	return nil
+}
+
+// alertmanagerNames returns the sorted names of all configured Alertmanagers.
+func (c *Client) alertmanagerNames() []string {
+	names := make([]string, 0, len(c.Alertmanagers))
+	for name := range c.Alertmanagers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// alertmanagers returns the names of the Alertmanagers selected by the given name for reading.
+// An empty name selects the default Alertmanager, or all Alertmanagers if no default is configured.
+func (c *Client) alertmanagers(name string) ([]string, error) {
+	if name == "" {
+		name = c.defaultAlertmanager
+	}
+
+	if name == "" || name == AllAlertmanagers {
+		return c.alertmanagerNames(), nil
+	}
+
+	if err := c.validateAlertmanager(name); err != nil {
+		return nil, err
+	}
+
+	return []string{name}, nil
+}
+
+// targetAlertmanagers returns the names of the Alertmanagers selected by the given name for writing.
+// An empty name selects the default Alertmanager, or the only Alertmanager if there is a single one.
+func (c *Client) targetAlertmanagers(name string) ([]string, error) {
+	if name == "" {
+		name = c.defaultAlertmanager
+	}
+
+	if name == "" {
+		switch len(c.Alertmanagers) {
+		case 0:
+			return nil, errNoAlertmanager
+		case 1:
+			return c.alertmanagerNames(), nil
+		default:
+			return nil, errAmbiguousAlertmanager
+		}
+	}
+
+	return c.alertmanagers(name)
+}
+
+// multipleAlertmanagers returns true if more than one Alertmanager is configured.
+func (c *Client) multipleAlertmanagers() bool {
+	return len(c.Alertmanagers) > 1
+}
+
+// alertmanagerArg removes the `--am` option from command arguments,
+// and returns the selected Alertmanager name and the remaining arguments.
+// The option is given as `--am=<name>` or `--am <name>`, like the options parsed by parseArgs.
+func alertmanagerArg(args []string) (string, []string) {
+	name := ""
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		option, value, hasValue := splitOption(args[i])
+
+		switch {
+		case option != alertmanagerOption:
+			rest = append(rest, args[i])
+		case hasValue:
+			name = value
+		case i+1 < len(args):
+			i++
+			name = args[i]
+		}
+	}
+
+	return name, rest
+}
+
+// hasAlertmanagerArg returns true if the arguments contain the `--am` option.
+func hasAlertmanagerArg(args []string) bool {
+	for _, arg := range args {
+		if option, _, _ := splitOption(arg); option == alertmanagerOption {
+			return true
+		}
+	}
+
+	return false
+}
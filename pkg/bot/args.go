@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	errUnknownOption     = errors.New("unknown option")
+	errUnterminatedQuote = errors.New("unterminated quote")
+	errMissingValue      = errors.New("missing value for option")
+)
+
+// commandArgs contains the parsed arguments of a command.
+type commandArgs struct {
+	options map[string]string // Values of the given options by name, eg: `--comment`.
+	flags   map[string]bool   // Flags that are set, eg: `--confirm`.
+	args    []string          // Remaining positional arguments.
+}
+
+// splitArgs splits the text of a command into arguments separated by whitespace.
+// An argument, or the value of an option like `--name="value"`, that starts with a single or double quote
+// continues until the matching quote and may contain whitespace.
+// The quotes are removed, and a backslash escapes the next character within double quotes.
+// Quotes elsewhere have no special meaning, so that words like `it's` and matchers like `job="a"` are kept as is.
+func splitArgs(text string) ([]string, error) {
+	return scanArgs(text, false)
+}
+
+// splitRawArgs splits the text of a command into arguments like splitArgs,
+// but keeps the quotes and escapes, so that the arguments can be parsed by parseArgs.
+func splitRawArgs(text string) ([]string, error) {
+	return scanArgs(text, true)
+}
+
+// scanArgs splits the text of a command into arguments, see splitArgs.
+// Quotes and escapes are kept in the arguments if raw is set.
+func scanArgs(text string, raw bool) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		escaped bool
+		inArg   bool
+	)
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+
+			if !raw {
+				continue
+			}
+		case quote != 0 && r == quote:
+			quote = 0
+
+			if !raw {
+				continue
+			}
+		case quote != 0:
+		case (r == '"' || r == '\'') && (!inArg || optionValueStart(current.String())):
+			quote = r
+			inArg = true
+
+			if !raw {
+				continue
+			}
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+			}
+
+			inArg = false
+
+			continue
+		}
+
+		current.WriteRune(r)
+
+		inArg = true
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: %s", errUnterminatedQuote, string(quote))
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// optionValueStart returns true if the argument is an option that ends with the `=` preceding its value.
+func optionValueStart(arg string) bool {
+	return strings.HasPrefix(arg, "--") && strings.Index(arg, "=") == len(arg)-1
+}
+
+// splitOption splits an option given as `--name=value` into its name and value.
+// False is returned if the option does not contain a value.
+func splitOption(arg string) (string, string, bool) {
+	sep := strings.Index(arg, "=")
+	if sep < 0 {
+		return arg, "", false
+	}
+
+	return arg[:sep], arg[sep+1:], true
+}
+
+// unquoteArg removes the quotes and escapes from an argument split by splitRawArgs.
+func unquoteArg(arg string) (string, error) {
+	args, err := splitArgs(arg)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// parseArgs parses the given options, flags and positional arguments of a command,
+// as split by splitRawArgs, so that quoted arguments and option values may contain whitespace.
+// Options are given as `--name=value` or `--name value`.
+// Flags are given as `--name` and do not have a value.
+// An error is returned for unterminated quotes, unknown options and options without a value.
+func parseArgs(args, options, flags []string) (*commandArgs, error) {
+	args = append([]string{}, args...)
+
+	for i := range args {
+		arg, err := unquoteArg(args[i])
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = arg
+	}
+
+	parsed := &commandArgs{options: make(map[string]string), flags: make(map[string]bool)}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			parsed.args = append(parsed.args, arg)
+			continue
+		}
+
+		if contains(flags, arg) {
+			parsed.flags[arg] = true
+			continue
+		}
+
+		name, value, hasValue := splitOption(arg)
+		if !contains(options, name) {
+			return nil, fmt.Errorf("%w: %q", errUnknownOption, name)
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%w: %q", errMissingValue, name)
+			}
+
+			i++
+			value = args[i]
+		}
+
+		parsed.options[name] = value
+	}
+
+	return parsed, nil
+}
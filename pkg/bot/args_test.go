@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		args []string
+		err  error
+	}{
+		{name: "empty", text: "  ", args: nil},
+		{name: "fields", text: "silence add  1h\tjob=test", args: []string{"silence", "add", "1h", "job=test"}},
+		{name: "apostrophe", text: "it's broken", args: []string{"it's", "broken"}},
+		{name: "double quotes", text: `"kernel upgrade" now`, args: []string{"kernel upgrade", "now"}},
+		{name: "single quotes", text: `'kernel "upgrade"'`, args: []string{`kernel "upgrade"`}},
+		{name: "escape", text: `"a \"b\" \\c"`, args: []string{`a "b" \c`}},
+		{name: "empty quotes", text: `--comment ""`, args: []string{"--comment", ""}},
+		{name: "option value", text: `--comment="a b" 1h`, args: []string{"--comment=a b", "1h"}},
+		{name: "option value single", text: `--comment='a "b"'`, args: []string{`--comment=a "b"`}},
+		{name: "matcher", text: `job="a" instance=~'b.*'`, args: []string{`job="a"`, `instance=~'b.*'`}},
+		{name: "unterminated", text: `"kernel upgrade`, err: errUnterminatedQuote},
+		{name: "unterminated option", text: `--comment='it`, err: errUnterminatedQuote},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			args, err := splitArgs(test.text)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("Expected %q, got %q", test.args, args)
+			}
+		})
+	}
+}
+
+func TestSplitRawArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		args []string
+		err  error
+	}{
+		{name: "fields", text: "silence add  1h\tjob=test", args: []string{"silence", "add", "1h", "job=test"}},
+		{name: "quotes", text: `"kernel  upgrade" it's`, args: []string{`"kernel  upgrade"`, "it's"}},
+		{name: "escape", text: `"a \"b\""`, args: []string{`"a \"b\""`}},
+		{name: "option value", text: `--comment='a  b' 1h`, args: []string{"--comment='a  b'", "1h"}},
+		{name: "unterminated", text: `"kernel upgrade`, err: errUnterminatedQuote},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			args, err := splitRawArgs(test.text)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("Expected %q, got %q", test.args, args)
+			}
+		})
+	}
+}
+
+func TestParseArgsWhitespace(t *testing.T) {
+	for _, text := range []string{`--comment "a  b"`, `--comment="a  b"`, `--comment 'a  b'`} {
+		args, err := splitRawArgs(text)
+		if err != nil {
+			t.Fatalf("Error splitting %q: %s", text, err)
+		}
+
+		parsed, err := parseArgs(args, []string{commentOption}, nil)
+		if err != nil {
+			t.Fatalf("Error parsing %q: %s", text, err)
+		}
+
+		if comment := parsed.options[commentOption]; comment != "a  b" {
+			t.Errorf("Expected the comment of %q to keep its whitespace, got %q", text, comment)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	options := []string{commentOption, atOption}
+	flags := []string{confirmOption}
+
+	tests := []struct {
+		name   string
+		args   []string
+		parsed *commandArgs
+		err    error
+	}{
+		{
+			name:   "positional",
+			args:   []string{"1h", `job="a"`},
+			parsed: &commandArgs{args: []string{"1h", `job="a"`}},
+		},
+		{
+			name: "option with equals sign",
+			args: []string{"1h", `--comment="kernel upgrade"`, "job=a"},
+			parsed: &commandArgs{
+				options: map[string]string{commentOption: "kernel upgrade"},
+				args:    []string{"1h", "job=a"},
+			},
+		},
+		{
+			name: "option with space",
+			args: []string{"--at", "12:00", "--comment", `"it's  broken"`, "1h"},
+			parsed: &commandArgs{
+				options: map[string]string{atOption: "12:00", commentOption: "it's  broken"},
+				args:    []string{"1h"},
+			},
+		},
+		{
+			name: "flag",
+			args: []string{"1h", confirmOption, "job=a"},
+			parsed: &commandArgs{
+				flags: map[string]bool{confirmOption: true},
+				args:  []string{"1h", "job=a"},
+			},
+		},
+		{name: "unknown option", args: []string{"--foo=bar"}, err: errUnknownOption},
+		{name: "unknown flag", args: []string{"--foo"}, err: errUnknownOption},
+		{name: "missing value", args: []string{"1h", commentOption}, err: errMissingValue},
+		{name: "unterminated quote", args: []string{`--comment="a`}, err: errUnterminatedQuote},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := parseArgs(test.args, options, flags)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+
+			if test.parsed == nil {
+				return
+			}
+
+			if len(parsed.options) != len(test.parsed.options) || len(parsed.flags) != len(test.parsed.flags) {
+				t.Errorf("Expected options %v and flags %v, got %v and %v",
+					test.parsed.options, test.parsed.flags, parsed.options, parsed.flags)
+			}
+
+			for name, value := range test.parsed.options {
+				if parsed.options[name] != value {
+					t.Errorf("Expected %s to be %q, got %q", name, value, parsed.options[name])
+				}
+			}
+
+			for name := range test.parsed.flags {
+				if !parsed.flags[name] {
+					t.Errorf("Expected flag %s to be set", name)
+				}
+			}
+
+			if !reflect.DeepEqual(parsed.args, test.parsed.args) {
+				t.Errorf("Expected arguments %q, got %q", test.parsed.args, parsed.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// WebhookAuth contains the credentials that webhook requests for a room must provide.
+// Requests are accepted if they match any of the configured credentials.
+type WebhookAuth struct {
+	BearerToken string // Bearer token (optional).
+	Username    string // Username for HTTP basic authentication (optional).
+	Password    string // Password for HTTP basic authentication (optional).
+}
+
+// Enabled returns true if any credentials are configured.
+func (a *WebhookAuth) Enabled() bool {
+	return a != nil && (a.BearerToken != "" || a.Username != "")
+}
+
+// Authorized returns true if the request contains valid credentials,
+// or if no credentials are configured.
+func (a *WebhookAuth) Authorized(r *http.Request) bool {
+	if !a.Enabled() {
+		return true
+	}
+
+	if a.BearerToken != "" && equal(r.Header.Get("Authorization"), "Bearer "+a.BearerToken) {
+		return true
+	}
+
+	if a.Username != "" {
+		username, password, ok := r.BasicAuth()
+
+		// Both are always compared to keep the comparison constant-time
+		validUser := equal(username, a.Username)
+		validPassword := equal(password, a.Password)
+
+		return ok && validUser && validPassword
+	}
+
+	return false
+}
+
+// equal compares two strings in constant time.
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// WebhookAuthorized returns true if the request contains the credentials configured for the client,
+// or those of any configured room.
+// This allows rejecting requests before the rooms they are sent to are known.
+func (c *Client) WebhookAuthorized(r *http.Request) bool {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	if c.webhookAuth.Authorized(r) {
+		return true
+	}
+
+	for _, room := range c.rooms {
+		if room.WebhookAuth.Enabled() && room.WebhookAuth.Authorized(r) {
+			return true
+		}
+	}
+
+	return false
+}
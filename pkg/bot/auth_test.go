@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newAuthRequest returns a webhook request with the given bearer token or basic authentication credentials.
+func newAuthRequest(token, username, password string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	switch {
+	case token != "":
+		r.Header.Set("Authorization", "Bearer "+token)
+	case username != "":
+		r.SetBasicAuth(username, password)
+	}
+
+	return r
+}
+
+func TestWebhookAuthAuthorized(t *testing.T) {
+	bearer := &WebhookAuth{BearerToken: "secret"}
+	basic := &WebhookAuth{Username: "alertmanager", Password: "password"}
+	both := &WebhookAuth{BearerToken: "secret", Username: "alertmanager", Password: "password"}
+
+	tests := []struct {
+		name       string
+		auth       *WebhookAuth
+		request    *http.Request
+		authorized bool
+	}{
+		{name: "none configured", auth: nil, request: newAuthRequest("", "", ""), authorized: true},
+		{name: "empty configured", auth: &WebhookAuth{}, request: newAuthRequest("", "", ""), authorized: true},
+		{name: "bearer", auth: bearer, request: newAuthRequest("secret", "", ""), authorized: true},
+		{name: "bearer wrong", auth: bearer, request: newAuthRequest("wrong", "", ""), authorized: false},
+		{name: "bearer prefix", auth: bearer, request: newAuthRequest("secret2", "", ""), authorized: false},
+		{name: "bearer missing", auth: bearer, request: newAuthRequest("", "", ""), authorized: false},
+		{name: "bearer as basic", auth: bearer, request: newAuthRequest("", "secret", "secret"), authorized: false},
+		{name: "basic", auth: basic, request: newAuthRequest("", "alertmanager", "password"), authorized: true},
+		{name: "basic wrong user", auth: basic, request: newAuthRequest("", "other", "password"), authorized: false},
+		{name: "basic wrong password", auth: basic, request: newAuthRequest("", "alertmanager", "wrong"), authorized: false},
+		{name: "basic missing", auth: basic, request: newAuthRequest("", "", ""), authorized: false},
+		{name: "basic as bearer", auth: basic, request: newAuthRequest("password", "", ""), authorized: false},
+		{name: "both bearer", auth: both, request: newAuthRequest("secret", "", ""), authorized: true},
+		{name: "both basic", auth: both, request: newAuthRequest("", "alertmanager", "password"), authorized: true},
+		{name: "both wrong", auth: both, request: newAuthRequest("wrong", "", ""), authorized: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if authorized := test.auth.Authorized(test.request); authorized != test.authorized {
+				t.Errorf("Expected authorized to be %v, got %v", test.authorized, authorized)
+			}
+		})
+	}
+}
+
+func TestRoomWebhookAuth(t *testing.T) {
+	global := &WebhookAuth{BearerToken: "global"}
+	room := &WebhookAuth{BearerToken: "room"}
+
+	// Resolve the alias of the room with its own credentials
+	client := newTestClient(t, testHandlers{
+		"/directory/room/#team:example.com": respond(`{"room_id":"!team:example.com"}`),
+	}, &ClientConfig{
+		WebhookAuth: global,
+		RoomConfigs: map[string]*RoomConfig{
+			"#team:example.com":  {WebhookAuth: room},
+			"!other:example.com": {},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		roomID     string
+		token      string
+		authorized bool
+	}{
+		{name: "room with credentials", roomID: "!team:example.com", token: "room", authorized: true},
+		{name: "room with global credentials", roomID: "!team:example.com", token: "global", authorized: false},
+		{name: "room without credentials", roomID: "!team:example.com", token: "", authorized: false},
+		{name: "configured room", roomID: "!other:example.com", token: "global", authorized: true},
+		{name: "configured room with room credentials", roomID: "!other:example.com", token: "room", authorized: false},
+		{name: "unknown room", roomID: "!unknown:example.com", token: "global", authorized: true},
+		{name: "unknown room without credentials", roomID: "!unknown:example.com", token: "", authorized: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r := newAuthRequest(test.token, "", "")
+
+			if authorized := client.RoomConfig(test.roomID).WebhookAuth.Authorized(r); authorized != test.authorized {
+				t.Errorf("Expected authorized to be %v, got %v", test.authorized, authorized)
+			}
+		})
+	}
+
+	if auth := client.RoomWebhookAuth("!team:example.com"); auth != room {
+		t.Errorf("Expected the credentials of the room before it is joined, got %+v", auth)
+	}
+
+	if auth := client.RoomWebhookAuth("!other:example.com"); auth != nil {
+		t.Errorf("Expected no credentials of the room itself, got %+v", auth)
+	}
+
+	for _, token := range []string{"global", "room"} {
+		if !client.WebhookAuthorized(newAuthRequest(token, "", "")) {
+			t.Errorf("Expected %s credentials to be accepted before resolving a room", token)
+		}
+	}
+
+	if client.WebhookAuthorized(newAuthRequest("wrong", "", "")) {
+		t.Error("Expected wrong credentials to be rejected before resolving a room")
+	}
+}
@@ -0,0 +1,217 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+var (
+	errInsufficientArgs = errors.New("insufficient arguments")
+	errCommandFailed    = errors.New("command failed")
+)
+
+// handlerFunc handles a command with the arguments following the command path.
+// An error is returned with the response if the command failed.
+type handlerFunc func(sender string, args []string) (*bot.Message, error)
+
+// handleMessage handles commands sent to the bot in room messages.
+func (c *Client) handleMessage(e *bot.Event) {
+	room := c.Matrix.NewRoom(e.RoomID)
+	if e.Sender == c.Matrix.Client.UserID || !c.roomAllowed(room.ID) {
+		return
+	}
+
+	text, ok := e.Body()
+	if !ok {
+		return
+	}
+
+	text, ok = c.trimCommandPrefix(text)
+	if !ok {
+		return
+	}
+
+	config := c.roomConfig(room.ID)
+
+	// Quoted arguments are only unquoted by commands that have options, see parseArgs.
+	// Unterminated quotes are reported by those commands as well.
+	args, err := splitRawArgs(text)
+	if err != nil {
+		args = strings.Fields(text)
+	}
+
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	var (
+		response *bot.Message
+		pending  *confirmation
+	)
+
+	path := c.commandPath(args)
+	if path == "" && args[0] == confirmCommand {
+		path = confirmCommand
+	}
+
+	// Use the Alertmanager of the room for commands that accept it, unless one is selected explicitly
+	if _, ok := c.handlers[path]; ok && config.Alertmanager != "" && !hasAlertmanagerArg(args) {
+		args = append(args, alertmanagerOption+"="+config.Alertmanager)
+	}
+
+	outcome := outcomeSuccess
+
+	switch {
+	case path == confirmCommand:
+		response, err = c.confirm(room.ID, e.Sender, args[1:])
+	case !config.CommandAllowed(path):
+		outcome = outcomeDenied
+		response = bot.NewMarkdownMessage(fmt.Sprintf("command not allowed in this room: %q", path))
+	case path == "" && args[0] != "":
+		outcome = outcomeUnknown
+		response = c.rootCommand().Execute(e.Sender, "", args...)
+	default:
+		pending, response = c.requestConfirmation(room.ID, e.Sender, path, args)
+		if response == nil {
+			response, err = c.execute(e.Sender, path, args)
+		}
+	}
+
+	if err != nil {
+		log.Printf("Error executing command %q: %s", path, err)
+
+		outcome = outcomeError
+	}
+
+	if response != nil {
+		if response.MsgType == "" {
+			response.MsgType = config.MessageType
+		}
+
+		eventID, err := c.sendMessage(room.ID, response)
+		if err != nil {
+			log.Printf("Error sending message: %s", err)
+
+			if outcome == outcomeSuccess {
+				outcome = outcomeFailure
+			}
+		} else if pending != nil {
+			c.addConfirmation(pending, eventID)
+		}
+	}
+
+	commandsTotal.WithLabelValues(path, outcome).Inc()
+}
+
+// trimCommandPrefix removes the command prefix or highlight from a message,
+// matching them like the Matrix bot does.
+// False is returned if the message is not a command.
+func (c *Client) trimCommandPrefix(text string) (string, bool) {
+	highlights := !c.Matrix.Config.IgnoreHighlights
+
+	if highlights && strings.HasPrefix(text, c.Matrix.Client.UserID+": ") {
+		return strings.TrimPrefix(text, c.Matrix.Client.UserID+": "), true
+	}
+
+	for _, prefix := range c.Matrix.Config.CommandPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return strings.TrimPrefix(text, prefix), true
+		}
+	}
+
+	if !highlights {
+		return "", false
+	}
+
+	resp, err := c.Matrix.Client.GetOwnDisplayName()
+	if err == nil && resp.DisplayName != "" && strings.HasPrefix(text, resp.DisplayName+": ") {
+		return strings.TrimPrefix(text, resp.DisplayName+": "), true
+	}
+
+	return "", false
+}
+
+// rootCommand returns the command containing all registered commands.
+func (c *Client) rootCommand() *bot.Command {
+	return &bot.Command{
+		Subcommands: c.Matrix.Config.Commands,
+		MessageHandler: func(_, cmd string, args ...string) *bot.Message {
+			if len(args) > 0 {
+				cmd = args[0]
+			}
+
+			return bot.NewMarkdownMessage(fmt.Sprintf("unknown command: %q", cmd))
+		},
+	}
+}
+
+// handler registers a handler for the command with the given path,
+// and returns a message handler for the command.
+// Commands with a handler accept the `--am` option for selecting the Alertmanager.
+func (c *Client) handler(path string, f handlerFunc) func(sender, cmd string, args ...string) *bot.Message {
+	c.handlers[path] = f
+
+	return func(sender, _ string, args ...string) *bot.Message {
+		msg, _ := f(sender, args)
+
+		return msg
+	}
+}
+
+// execute executes the command with the given path and arguments, including the path,
+// and returns the response and an error if the command failed.
+func (c *Client) execute(sender, path string, args []string) (*bot.Message, error) {
+	f, ok := c.handlers[path]
+	if !ok {
+		return c.rootCommand().Execute(sender, "", args...), nil
+	}
+
+	// The bare command is registered with an empty name
+	n := len(strings.Fields(path))
+	if path == "" {
+		n = 1
+	}
+
+	return f(sender, args[n:])
+}
+
+// errorMessage returns a response containing an error, and the error.
+func errorMessage(err error) (*bot.Message, error) {
+	return bot.NewTextMessage(err.Error()), err
+}
+
+// insufficientArgs returns the response and error for a command with insufficient arguments.
+func insufficientArgs() (*bot.Message, error) {
+	return bot.NewTextMessage("Insufficient arguments."), errInsufficientArgs
+}
+
+// commandError returns an error containing the given error messages, or nil if there are none.
+func commandError(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errCommandFailed, strings.Join(errs, "; "))
+}
+
+// commandPath returns the path of the command that is executed for the given arguments.
+func (c *Client) commandPath(args []string) string {
+	path := make([]string, 0, len(args))
+	cmd := c.rootCommand()
+
+	for _, arg := range args {
+		sub, ok := cmd.Subcommands[arg]
+		if !ok || sub.MessageHandler == nil {
+			break
+		}
+
+		path = append(path, arg)
+		cmd = sub
+	}
+
+	return strings.Join(path, " ")
+}
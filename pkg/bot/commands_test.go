@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/matrix-org/gomatrix"
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+func TestTrimCommandPrefix(t *testing.T) {
+	client := newTestClient(t, testHandlers{
+		"/profile/@bot:example.com/displayname": respond(`{"displayname":"Bot"}`),
+	}, nil)
+
+	tests := []struct {
+		text             string
+		ignoreHighlights bool
+		expected         string
+		ok               bool
+	}{
+		{text: "!alert list", expected: " list", ok: true},
+		{text: "!alertmanager list", expected: " list", ok: true},
+		{text: "@bot:example.com: list", expected: "list", ok: true},
+		{text: "Bot: list", expected: "list", ok: true},
+		{text: "!alert list", ignoreHighlights: true, expected: " list", ok: true},
+		{text: "@bot:example.com: list", ignoreHighlights: true},
+		{text: "Bot: list", ignoreHighlights: true},
+		{text: "list"},
+	}
+
+	for _, test := range tests {
+		client.Matrix.Config.IgnoreHighlights = test.ignoreHighlights
+
+		text, ok := client.trimCommandPrefix(test.text)
+		if text != test.expected || ok != test.ok {
+			t.Errorf("Expected %q (%v) for %q, got %q (%v)", test.expected, test.ok, test.text, text, ok)
+		}
+	}
+}
+
+func TestRoomAlertmanagerArg(t *testing.T) {
+	var received []string
+
+	client := newTestClient(t, testHandlers{"/send/": respond(`{"event_id":"$response"}`)}, &ClientConfig{
+		Alertmanagers: map[string]*AlertmanagerConfig{"prod": {URLs: []string{"http://localhost:9093"}}},
+		RoomConfigs:   map[string]*RoomConfig{"!room:example.com": {Alertmanager: "prod"}},
+	})
+
+	client.Matrix.SetCommand("test", &bot.Command{
+		MessageHandler: client.handler("test", func(sender string, args []string) (*bot.Message, error) {
+			received = args
+
+			return bot.NewTextMessage("test"), nil
+		}),
+	})
+
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "!alert test a", expected: []string{"a", "--am=prod"}},
+		{text: "!alert test a --am=other", expected: []string{"a", "--am=other"}},
+		{text: "!alert help test"},
+		{text: "!alert unknown"},
+	}
+
+	for _, test := range tests {
+		received = nil
+
+		client.handleMessage(&bot.Event{Event: &gomatrix.Event{
+			RoomID:  "!room:example.com",
+			Sender:  "@user:example.com",
+			Type:    string(bot.EventTypeRoomMessage),
+			Content: map[string]interface{}{"msgtype": "m.text", "body": test.text},
+		}})
+
+		if !reflect.DeepEqual(received, test.expected) {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.text, received)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+// Confirmation of commands.
+const (
+	confirmCommand        = "confirm" // Command for confirming a command using its code.
+	confirmReaction       = "✅"       // Reaction for confirming a command.
+	confirmCodeLength     = 3         // Number of random bytes in a confirmation code.
+	defaultConfirmTimeout = "5m"      // Default duration in which a command must be confirmed.
+)
+
+var (
+	errUnknownCommand        = errors.New("unknown command")
+	errNoConfirmation        = errors.New("no command to confirm with this code")
+	errConfirmationExpired   = errors.New("confirmation has expired, please repeat the command")
+	errConfirmationForbidden = errors.New("only the sender of a command can confirm it")
+)
+
+// ConfirmConfig contains the configuration of commands that require confirmation before they are executed.
+type ConfirmConfig struct {
+	// Commands contains the commands that require confirmation, eg: `silence del`,
+	// with the number of affected items above which confirmation is required.
+	// Items are matched alerts for `silence add` and silence IDs for `silence del`.
+	// Other commands affect a single item, and require confirmation if the threshold is 0.
+	Commands map[string]int
+
+	Timeout string // Duration in which a command must be confirmed (optional).
+}
+
+// confirmation represents a command that awaits confirmation.
+type confirmation struct {
+	code    string    // Code for confirming the command.
+	roomID  string    // Room the command was sent in.
+	sender  string    // Sender of the command.
+	path    string    // Path of the command, eg: `silence add`.
+	args    []string  // Arguments of the command, including the path.
+	expires time.Time // Time after which the command can no longer be confirmed.
+	eventID string    // ID of the message requesting confirmation.
+}
+
+// confirmCounter returns the number of items affected by a command with the given arguments,
+// and optionally a message summarizing them.
+type confirmCounter func(sender string, args []string) (int, *bot.Message)
+
+// validateConfirmConfig validates the configuration of commands that require confirmation.
+// New silences require confirmation if they match more alerts than the silence threshold, if set,
+// unless the configuration contains another threshold for `silence add`.
+// The commands must be registered before the configuration is validated.
+func (c *Client) validateConfirmConfig(config *ConfirmConfig, silenceThreshold int) (err error) {
+	c.confirmations = make(map[string]*confirmation)
+	commands := make(map[string]int)
+	timeout := defaultConfirmTimeout
+
+	if silenceThreshold > 0 {
+		commands[silenceAddCommand] = silenceThreshold
+	}
+
+	if config != nil {
+		for path, threshold := range config.Commands {
+			commands[path] = threshold
+		}
+
+		if config.Timeout != "" {
+			timeout = config.Timeout
+		}
+	}
+
+	if len(commands) == 0 {
+		return nil
+	}
+
+	if c.confirmTimeout, err = parseDuration(timeout); err != nil {
+		return fmt.Errorf("invalid confirmation timeout: %w", err)
+	}
+
+	c.confirmCommands = make(map[string]int, len(commands))
+
+	for path, threshold := range commands {
+		if path == "" || c.commandPath(strings.Fields(path)) != path {
+			return fmt.Errorf("invalid confirmation configuration: %w: %q", errUnknownCommand, path)
+		}
+
+		c.confirmCommands[path] = threshold
+	}
+
+	return nil
+}
+
+// confirmCounters returns the functions counting the items affected by commands, by command path.
+func (c *Client) confirmCounters() map[string]confirmCounter {
+	return map[string]confirmCounter{
+		silenceAddCommand: c.silenceAddCount,
+		silenceDelCommand: silenceDelCount,
+	}
+}
+
+// silenceAddCount returns the number of firing alerts matched by the `silence add` command, and a list of them.
+// Silences given with `--confirm` are confirmed already, and do not match any alerts that need confirmation.
+func (c *Client) silenceAddCount(_ string, args []string) (int, *bot.Message) {
+	am, args := alertmanagerArg(args)
+
+	parsed, err := parseArgs(args, []string{commentOption, atOption}, []string{confirmOption})
+	if err != nil || parsed.flags[confirmOption] || len(parsed.args) <= 1 {
+		return 0, nil
+	}
+
+	names, err := c.targetAlertmanagers(am)
+	if err != nil {
+		return 0, nil
+	}
+
+	matches, messages := c.matchSilence(names, strings.Join(parsed.args[1:], " "))
+	alerts := matchedAlerts(matches)
+
+	return len(alerts), c.matchesMessage(append(messages, matchCount(alerts)), alerts)
+}
+
+// silenceDelCount returns the number of silences deleted by the `silence del` command.
+func silenceDelCount(_ string, args []string) (int, *bot.Message) {
+	_, ids := alertmanagerArg(args)
+
+	return len(ids), bot.NewMarkdownMessage(fmt.Sprintf("Silences to delete: *%s*", strings.Join(ids, ", ")))
+}
+
+// requestConfirmation returns a confirmation and a message requesting it
+// if the command with the given path and arguments requires confirmation.
+// The confirmation must be added using addConfirmation after the message is sent.
+func (c *Client) requestConfirmation(roomID, sender, path string, args []string) (*confirmation, *bot.Message) {
+	threshold, ok := c.confirmCommands[path]
+	if !ok {
+		return nil, nil
+	}
+
+	count, summary := 1, (*bot.Message)(nil)
+	if counter, ok := c.confirmCounters()[path]; ok {
+		count, summary = counter(sender, args[len(strings.Fields(path)):])
+	}
+
+	if count <= threshold {
+		return nil, nil
+	}
+
+	code, err := c.confirmationCode()
+	if err != nil {
+		log.Printf("Error generating confirmation code: %s", err)
+
+		return nil, bot.NewTextMessage("Error requesting confirmation, please try again.")
+	}
+
+	p := &confirmation{
+		code:    code,
+		roomID:  roomID,
+		sender:  sender,
+		path:    path,
+		args:    append([]string(nil), args...),
+		expires: time.Now().Add(c.confirmTimeout),
+	}
+
+	msg := bot.NewMarkdownMessage(fmt.Sprintf(
+		"Reply `%s %s` or react with %s within %s to execute `%s`.",
+		confirmCommand, code, confirmReaction, c.confirmTimeout, strings.Join(args, " ")))
+
+	if summary != nil {
+		formatted := summary.FormattedBody
+		if formatted == "" {
+			formatted = html.EscapeString(summary.Body) + "<br/>"
+		}
+
+		msg.Body = summary.Body + "\n\n" + msg.Body
+		msg.FormattedBody = formatted + msg.FormattedBody
+	}
+
+	return p, msg
+}
+
+// confirmationCode returns a random code that is not used by another confirmation.
+func (c *Client) confirmationCode() (string, error) {
+	c.confirmMu.Lock()
+	defer c.confirmMu.Unlock()
+
+	b := make([]byte, confirmCodeLength)
+
+	for {
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("error reading random data: %w", err)
+		}
+
+		code := hex.EncodeToString(b)
+		if _, ok := c.confirmations[code]; !ok {
+			return code, nil
+		}
+	}
+}
+
+// addConfirmation adds a confirmation with the ID of the message requesting it,
+// and removes expired confirmations.
+func (c *Client) addConfirmation(p *confirmation, eventID string) {
+	c.confirmMu.Lock()
+	defer c.confirmMu.Unlock()
+
+	for code, other := range c.confirmations {
+		if time.Now().After(other.expires) {
+			delete(c.confirmations, code)
+		}
+	}
+
+	p.eventID = eventID
+	c.confirmations[p.code] = p
+}
+
+// takeConfirmation removes and returns the first confirmation in a room that matches.
+// An error is returned if no confirmation matches, if it has expired, or if it was requested by another sender.
+func (c *Client) takeConfirmation(roomID, sender string, match func(*confirmation) bool) (*confirmation, error) {
+	c.confirmMu.Lock()
+	defer c.confirmMu.Unlock()
+
+	for code, p := range c.confirmations {
+		if p.roomID != roomID || !match(p) {
+			continue
+		}
+
+		if time.Now().After(p.expires) {
+			delete(c.confirmations, code)
+
+			return nil, errConfirmationExpired
+		}
+
+		if p.sender != sender {
+			return nil, errConfirmationForbidden
+		}
+
+		delete(c.confirmations, code)
+
+		return p, nil
+	}
+
+	return nil, errNoConfirmation
+}
+
+// confirm executes the command with the given confirmation code.
+func (c *Client) confirm(roomID, sender string, args []string) (*bot.Message, error) {
+	if len(args) != 1 {
+		return bot.NewTextMessage(fmt.Sprintf("Usage: %s <code>", confirmCommand)), errInsufficientArgs
+	}
+
+	p, err := c.takeConfirmation(roomID, sender, func(p *confirmation) bool {
+		return p.code == strings.ToLower(args[0])
+	})
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	return c.executeConfirmed(p)
+}
+
+// handleConfirmReaction executes the command confirmed by a reaction to the message requesting confirmation.
+func (c *Client) handleConfirmReaction(roomID, sender, eventID string) {
+	p, err := c.takeConfirmation(roomID, sender, func(p *confirmation) bool {
+		return p.eventID == eventID
+	})
+	if errors.Is(err, errNoConfirmation) {
+		return
+	}
+
+	if err != nil {
+		c.sendResponse(roomID, err.Error())
+		return
+	}
+
+	msg, err := c.executeConfirmed(p)
+	if err != nil {
+		log.Printf("Error executing command %q: %s", p.path, err)
+	}
+
+	if msg == nil {
+		return
+	}
+
+	msg.MsgType = c.roomConfig(roomID).MessageType
+
+	if _, err := c.sendMessage(roomID, msg); err != nil {
+		log.Printf("Error sending message: %s", err)
+	}
+}
+
+// executeConfirmed executes a confirmed command, and returns the response and an error if the command failed.
+func (c *Client) executeConfirmed(p *confirmation) (*bot.Message, error) {
+	return c.execute(p.sender, p.path, append([]string(nil), p.args...))
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSilenceConfirmation(t *testing.T) {
+	created := 0
+
+	alerts := `[` +
+		`{"labels":{"alertname":"A","job":"a"},"fingerprint":"0001","status":{"state":"active"}},` +
+		`{"labels":{"alertname":"B","job":"a"},"fingerprint":"0002","status":{"state":"active"}},` +
+		`{"labels":{"alertname":"C","job":"a"},"fingerprint":"0003","status":{"state":"active"}}]`
+
+	tests := []struct {
+		name      string
+		threshold int
+		confirm   *ConfirmConfig
+		confirmed bool
+		expected  bool
+	}{
+		{name: "disabled"},
+		{name: "below threshold", threshold: 3},
+		{name: "above threshold", threshold: 2, expected: true},
+		{name: "confirm option", threshold: 2, confirmed: true},
+		{
+			name:      "configured threshold",
+			threshold: 2,
+			confirm:   &ConfirmConfig{Commands: map[string]int{silenceAddCommand: 5}},
+		},
+		{name: "other command", confirm: &ConfirmConfig{Commands: map[string]int{silenceDelCommand: 0}}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, silenceHandlers(&created, alerts), &ClientConfig{
+				SilenceConfirmThreshold: test.threshold,
+				Confirm:                 test.confirm,
+			})
+
+			args := append(make([]string, 0, 8), "silence", "add", "1h", "job=a")
+			if test.confirmed {
+				args = append(args, confirmOption)
+			}
+
+			p, msg := client.requestConfirmation("!room:example.com", "@user:example.com", silenceAddCommand, args)
+			if (p != nil) != test.expected {
+				t.Fatalf("Expected confirmation to be required: %v, got %v", test.expected, p != nil)
+			}
+
+			if p == nil {
+				return
+			}
+
+			if !strings.Contains(msg.Body, "3 firing alert(s)") {
+				t.Errorf("Expected the matched alerts to be summarized, got:\n%s", msg.Body)
+			}
+
+			// The stored command must not share its arguments with the message
+			args[3] = "job=b"
+
+			if _, err := client.executeConfirmed(p); err != nil {
+				t.Fatalf("Error executing confirmed command: %s", err)
+			}
+
+			if strings.Join(p.args, " ") != "silence add 1h job=a" {
+				t.Errorf("Expected the confirmed command to be unchanged, got %q", p.args)
+			}
+
+			if created != 1 {
+				t.Errorf("Expected 1 silence to be created, got %d", created)
+			}
+
+			created = 0
+		})
+	}
+}
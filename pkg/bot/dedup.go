@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// dedupBucket is the store bucket containing the keys of recent notifications.
+const dedupBucket = "notifications"
+
+// notificationKey returns a key identifying the contents of a notification to a room.
+// Identical notifications sent by different Alertmanager replicas have the same key.
+func notificationKey(roomID string, message *alertmanager.Message) string {
+	alerts := make([]string, len(message.Alerts))
+
+	for i, a := range message.Alerts {
+		id := a.Fingerprint
+		if id == "" {
+			id = a.LabelSet().Fingerprint().String()
+		}
+
+		alerts[i] = fmt.Sprintf("%s %s %s %s",
+			id, a.Status, a.StartsAt.Format(time.RFC3339Nano), a.EndsAt.Format(time.RFC3339Nano))
+	}
+
+	sort.Strings(alerts)
+
+	hash := sha256.New()
+
+	_, _ = fmt.Fprintf(hash, "%q\n%q\n", message.GroupKey, message.Status)
+	for _, a := range alerts {
+		_, _ = fmt.Fprintln(hash, a)
+	}
+
+	return roomID + " " + hex.EncodeToString(hash.Sum(nil))
+}
+
+// isDuplicate returns true if an identical notification was sent to the room within the deduplication window.
+// The notification is remembered otherwise.
+func (c *Client) isDuplicate(key string) (bool, error) {
+	if c.dedupWindow <= 0 {
+		return false, nil
+	}
+
+	c.dedupMu.Lock()
+	defer c.dedupMu.Unlock()
+
+	if err := c.store.Prune(dedupBucket, c.dedupWindow); err != nil {
+		return false, fmt.Errorf("error storing notification state: %w", err)
+	}
+
+	if _, ok := c.store.Get(dedupBucket, key); ok {
+		return true, nil
+	}
+
+	if err := c.store.Set(dedupBucket, key, ""); err != nil {
+		return false, fmt.Errorf("error storing notification state: %w", err)
+	}
+
+	return false, nil
+}
+
+// forgetNotification removes a notification from the deduplication state,
+// so that it is sent again when it is received again.
+func (c *Client) forgetNotification(key string) error {
+	if c.dedupWindow <= 0 {
+		return nil
+	}
+
+	c.dedupMu.Lock()
+	defer c.dedupMu.Unlock()
+
+	if err := c.store.Delete(dedupBucket, key); err != nil {
+		return fmt.Errorf("error storing notification state: %w", err)
+	}
+
+	return nil
+}
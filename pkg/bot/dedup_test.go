@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+func TestNotificationKey(t *testing.T) {
+	startsAt := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	message := func(modify func(m *alertmanager.Message)) *alertmanager.Message {
+		m := decodeMessage(t, `{"status":"firing","groupKey":"group","alerts":[
+			{"fingerprint":"a","status":"firing","startsAt":"2021-01-02T03:04:05Z"},
+			{"fingerprint":"b","status":"resolved","startsAt":"2021-01-02T03:04:05Z","endsAt":"2021-01-02T04:04:05Z"},
+			{"status":"firing","startsAt":"2021-01-02T03:04:05Z","labels":{"alertname":"NoFingerprint"}}]}`)
+		if modify != nil {
+			modify(m)
+		}
+
+		return m
+	}
+
+	key := notificationKey("!room:example.com", message(nil))
+
+	tests := []struct {
+		name    string
+		roomID  string
+		message *alertmanager.Message
+		equal   bool
+	}{
+		{name: "identical", roomID: "!room:example.com", message: message(nil), equal: true},
+		{
+			name:   "alert order",
+			roomID: "!room:example.com",
+			message: message(func(m *alertmanager.Message) {
+				m.Alerts[0], m.Alerts[1], m.Alerts[2] = m.Alerts[2], m.Alerts[0], m.Alerts[1]
+			}),
+			equal: true,
+		},
+		{
+			name:   "ignored fields",
+			roomID: "!room:example.com",
+			message: message(func(m *alertmanager.Message) {
+				m.Receiver = "other"
+				m.ExternalURL = "http://replica.example.com"
+				m.Alerts[0].GeneratorURL = "http://prometheus.example.com"
+			}),
+			equal: true,
+		},
+		{name: "room", roomID: "!other:example.com", message: message(nil)},
+		{name: "group", roomID: "!room:example.com", message: message(func(m *alertmanager.Message) { m.GroupKey = "other" })},
+		{name: "status", roomID: "!room:example.com", message: message(func(m *alertmanager.Message) { m.Status = "resolved" })},
+		{
+			name:    "alert status",
+			roomID:  "!room:example.com",
+			message: message(func(m *alertmanager.Message) { m.Alerts[0].Status = "resolved" }),
+		},
+		{
+			name:    "alert end",
+			roomID:  "!room:example.com",
+			message: message(func(m *alertmanager.Message) { m.Alerts[1].EndsAt = startsAt.Add(2 * time.Hour) }),
+		},
+		{
+			name:    "alert labels",
+			roomID:  "!room:example.com",
+			message: message(func(m *alertmanager.Message) { m.Alerts[2].Labels["alertname"] = "Other" }),
+		},
+		{
+			name:    "removed alert",
+			roomID:  "!room:example.com",
+			message: message(func(m *alertmanager.Message) { m.Alerts = m.Alerts[1:] }),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			other := notificationKey(test.roomID, test.message)
+			if (other == key) != test.equal {
+				t.Errorf("Expected equal keys to be %v, got %q and %q", test.equal, key, other)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+// Matrix relation types.
+const (
+	relationReplace = "m.replace"
+	relationThread  = "m.thread"
+)
+
+// relation represents the `m.relates_to` field of a Matrix event.
+type relation struct {
+	RelType string `json:"rel_type,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+
+	// Fields for thread relations
+	IsFallingBack bool       `json:"is_falling_back,omitempty"`
+	InReplyTo     *inReplyTo `json:"m.in_reply_to,omitempty"`
+}
+
+// inReplyTo represents the `m.in_reply_to` field of a Matrix relation.
+type inReplyTo struct {
+	EventID string `json:"event_id"`
+}
+
+// eventContent represents the content of a Matrix message event
+// that can be related to other events.
+type eventContent struct {
+	*bot.Message
+	NewContent *bot.Message `json:"m.new_content,omitempty"`
+	RelatesTo  *relation    `json:"m.relates_to,omitempty"`
+}
+
+// newReplacement returns the event content for an edit of the given event.
+func newReplacement(eventID string, message *bot.Message) *eventContent {
+	fallback := *message
+	fallback.Body = "* " + message.Body
+
+	if fallback.FormattedBody != "" {
+		fallback.FormattedBody = "* " + message.FormattedBody
+	}
+
+	return &eventContent{
+		Message:    &fallback,
+		NewContent: message,
+		RelatesTo:  &relation{RelType: relationReplace, EventID: eventID},
+	}
+}
+
+// newThreadMessage returns the event content for a message in the thread of the given event.
+func newThreadMessage(eventID string, message *bot.Message) *eventContent {
+	return &eventContent{
+		Message: message,
+		RelatesTo: &relation{
+			RelType:       relationThread,
+			EventID:       eventID,
+			IsFallingBack: true,
+			InReplyTo:     &inReplyTo{EventID: eventID},
+		},
+	}
+}
+
+// sendMessage sends a message event to a room and returns the event ID.
+func (c *Client) sendMessage(roomID string, content interface{}) (string, error) {
+	start := time.Now()
+	resp, err := c.Matrix.Client.SendMessageEvent(roomID, string(bot.EventTypeRoomMessage), content)
+
+	matrixSendDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		matrixSendFailures.Inc()
+
+		return "", fmt.Errorf("error sending message: %w", err)
+	}
+
+	return resp.EventID, nil
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+func TestEventContent(t *testing.T) {
+	text := &bot.Message{MsgType: "m.notice", Body: "firing"}
+	html := &bot.Message{
+		MsgType:       "m.notice",
+		Body:          "firing",
+		FormattedBody: "<b>firing</b>",
+		Format:        "org.matrix.custom.html",
+	}
+
+	tests := []struct {
+		name     string
+		content  *eventContent
+		expected string
+	}{
+		{
+			name:     "message",
+			content:  &eventContent{Message: text},
+			expected: `{"msgtype":"m.notice","body":"firing"}`,
+		},
+		{
+			name:    "replacement",
+			content: newReplacement("$event", text),
+			expected: `{"msgtype":"m.notice","body":"* firing",
+				"m.new_content":{"msgtype":"m.notice","body":"firing"},
+				"m.relates_to":{"rel_type":"m.replace","event_id":"$event"}}`,
+		},
+		{
+			name:    "formatted replacement",
+			content: newReplacement("$event", html),
+			expected: `{"msgtype":"m.notice","body":"* firing","formatted_body":"* <b>firing</b>",
+				"format":"org.matrix.custom.html",
+				"m.new_content":{"msgtype":"m.notice","body":"firing","formatted_body":"<b>firing</b>",
+					"format":"org.matrix.custom.html"},
+				"m.relates_to":{"rel_type":"m.replace","event_id":"$event"}}`,
+		},
+		{
+			name:    "thread",
+			content: newThreadMessage("$event", text),
+			expected: `{"msgtype":"m.notice","body":"firing",
+				"m.relates_to":{"rel_type":"m.thread","event_id":"$event","is_falling_back":true,
+					"m.in_reply_to":{"event_id":"$event"}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.content)
+			if err != nil {
+				t.Fatalf("Error marshalling content: %s", err)
+			}
+
+			var content, expected interface{}
+
+			if err = json.Unmarshal(data, &content); err != nil {
+				t.Fatalf("Error unmarshalling content: %s", err)
+			}
+
+			if err = json.Unmarshal([]byte(test.expected), &expected); err != nil {
+				t.Fatalf("Error unmarshalling expected content: %s", err)
+			}
+
+			if !reflect.DeepEqual(content, expected) {
+				t.Errorf("Expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+
+	if text.Body != "firing" || html.FormattedBody != "<b>firing</b>" {
+		t.Errorf("Expected the original messages to be unchanged, got %+v and %+v", text, html)
+	}
+}
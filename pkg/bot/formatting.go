@@ -3,8 +3,10 @@ package bot
 import (
 	"fmt"
 	html "html/template"
+	"io"
 	"strings"
 	text "text/template"
+	"time"
 
 	"github.com/prometheus/alertmanager/types"
 
@@ -13,8 +15,41 @@ import (
 
 // Default alert template values.
 const (
-	DefaultTextTemplate = "{{ range .Alerts }}{{.StatusString|icon}} {{.StatusString|upper}} {{.AlertName}}: {{.Summary}}{{if ne .Fingerprint \"\"}} ({{.Fingerprint}}){{end}}{{if $.ShowLabels}}, labels: {{.LabelString}}{{end}}\n{{ end -}}"                                                                              //nolint:lll
-	DefaultHTMLTemplate = `{{ range .Alerts }}<font color="{{.StatusString|color}}">{{.StatusString|icon}} <b>{{.StatusString|upper}}</b> {{.AlertName}}:</font> {{.Summary}}{{if ne .Fingerprint ""}} ({{.Fingerprint}}){{end}}{{if $.ShowLabels}}<br/><b>Labels:</b> <code>{{.LabelString}}</code>{{end}}<br/>{{- end -}}` //nolint:lll
+	DefaultTextTemplate = "{{ range .Alerts }}{{.StatusString|icon}} {{.StatusString|upper}} {{if .Alertmanager}}[{{.Alertmanager}}] {{end}}{{.AlertName}}: {{.Summary}}{{if ne .Fingerprint \"\"}} ({{.Fingerprint}}){{end}}{{if .AckedBy}}, acked by {{.AckedBy}}{{end}}{{if $.ShowLabels}}, labels: {{.LabelString}}{{end}}\n{{ end -}}{{if .TruncatedAlerts}}{{.TruncatedAlerts}} more alert(s) not shown\n{{end}}"                                                                                               //nolint:lll
+	DefaultHTMLTemplate = `{{ range .Alerts }}<font color="{{.StatusString|color}}">{{.StatusString|icon}} <b>{{.StatusString|upper}}</b> {{if .Alertmanager}}[{{.Alertmanager}}] {{end}}{{.AlertName}}:</font> {{.Summary}}{{if ne .Fingerprint ""}} ({{.Fingerprint}}){{end}}{{if .AckedBy}}, <i>acked by {{.AckedBy}}</i>{{end}}{{if $.ShowLabels}}<br/><b>Labels:</b> <code>{{.LabelString}}</code>{{end}}<br/>{{- end -}}{{if .TruncatedAlerts}}<i>{{.TruncatedAlerts}} more alert(s) not shown</i><br/>{{end}}` //nolint:lll
+)
+
+// Default alert list template values.
+const (
+	DefaultListTextTemplate = "{{.Total}} alerts, grouped by {{.GroupBy}}:\n{{range .Groups}}- {{.Name}}: {{.Total}} ({{range $i, $c := .Counts}}{{if $i}}, {{end}}{{$c.Status|icon}} {{$c.Count}} {{$c.Status}}{{end}})\n{{end}}Use `list --full` or `list page <n>` to show all alerts.\n"                                                                                                                //nolint:lll
+	DefaultListHTMLTemplate = `<b>{{.Total}} alerts</b>, grouped by <code>{{.GroupBy}}</code>:<ul>{{range .Groups}}<li><b>{{.Name}}</b>: {{.Total}} ({{range $i, $c := .Counts}}{{if $i}}, {{end}}<font color="{{$c.Status|color}}">{{$c.Status|icon}} {{$c.Count}} {{$c.Status}}</font>{{end}})</li>{{end}}</ul>Use <code>list --full</code> or <code>list page &lt;n&gt;</code> to show all alerts.<br/>` //nolint:lll
+)
+
+// Default alert detail template values.
+const (
+	DefaultDetailTextTemplate = "{{.StatusString|icon}} {{.StatusString|upper}} {{.AlertName}}: {{.Summary}}\n" +
+		"Fingerprint: {{.Fingerprint}}{{if .Alertmanager}} ({{.Alertmanager}}){{end}}\n" +
+		"State: {{.Status}}{{if .AckedBy}}, acked by {{.AckedBy}}{{end}}\n" +
+		"Started: {{.StartsAt.Format \"2006-01-02 15:04:05 MST\"}} ({{.Duration}} ago)\n" +
+		"{{if .Receivers}}Receivers: {{join .Receivers \", \"}}\n{{end}}" +
+		"Labels:\n{{range $k, $v := .Labels}}  {{$k}}: {{$v}}\n{{end}}" +
+		"{{if .Annotations}}Annotations:\n{{range $k, $v := .Annotations}}  {{$k}}: {{$v}}\n{{end}}{{end}}" +
+		"{{if .SilencedBy}}Silenced by: {{join .SilencedBy \", \"}}\n{{end}}" +
+		"{{if .InhibitedBy}}Inhibited by: {{join .InhibitedBy \", \"}}\n{{end}}" +
+		"{{if .GeneratorURL}}Source: {{.GeneratorURL}}\n{{end}}" +
+		"{{if .AlertURL}}Alertmanager: {{.AlertURL}}\n{{end}}"
+	DefaultDetailHTMLTemplate = `<font color="{{.StatusString|color}}">{{.StatusString|icon}} <b>{{.StatusString|upper}}</b> {{.AlertName}}:</font> {{.Summary}}<br/>` +
+		`<b>Fingerprint:</b> <code>{{.Fingerprint}}</code>{{if .Alertmanager}} ({{.Alertmanager}}){{end}}<br/>` +
+		`<b>State:</b> {{.Status}}{{if .AckedBy}}, <i>acked by {{.AckedBy}}</i>{{end}}<br/>` +
+		`<b>Started:</b> {{.StartsAt.Format "2006-01-02 15:04:05 MST"}} ({{.Duration}} ago)<br/>` +
+		`{{if .Receivers}}<b>Receivers:</b> {{join .Receivers ", "}}<br/>{{end}}` +
+		`<b>Labels:</b><ul>{{range $k, $v := .Labels}}<li>{{$k}}: <code>{{$v}}</code></li>{{end}}</ul>` +
+		`{{if .Annotations}}<b>Annotations:</b><ul>{{range $k, $v := .Annotations}}<li>{{$k}}: {{$v}}</li>{{end}}</ul>{{end}}` +
+		`{{if .SilencedBy}}<b>Silenced by:</b> <code>{{join .SilencedBy ", "}}</code><br/>{{end}}` +
+		`{{if .InhibitedBy}}<b>Inhibited by:</b> <code>{{join .InhibitedBy ", "}}</code><br/>{{end}}` +
+		`{{if .GeneratorURL}}<a href="{{.GeneratorURL}}">Source</a>{{end}}` +
+		`{{if and .GeneratorURL .AlertURL}} | {{end}}` +
+		`{{if .AlertURL}}<a href="{{.AlertURL}}">Alertmanager</a>{{end}}`
 )
 
 // Default color and icon values.
@@ -44,14 +79,21 @@ var (
 
 // Formatter represents a NewMessage formatter with an icon and color set.
 type Formatter struct {
-	colors map[string]string
-	icons  map[string]string
-	text   *text.Template
-	html   *html.Template
+	colors     map[string]string
+	icons      map[string]string
+	funcMap    map[string]interface{}
+	text       *text.Template
+	html       *html.Template
+	listText   *text.Template
+	listHTML   *html.Template
+	detailText *text.Template
+	detailHTML *html.Template
 }
 
 // NewFormatter creates a new formatter with the given text/HTML templates, colors and strings.
 // The default templates, colors or icons are used if "" or nil is provided.
+// The default alert list and detail templates are used, see ParseListTemplates and ParseDetailTemplates.
+// It panics if a template cannot be parsed.
 //
 // The following functions are registered for use in the templates:
 //
@@ -60,7 +102,19 @@ type Formatter struct {
 //	upper: converts the given string to uppercase.
 //	lower: converts the given string to lowercase.
 //	title: converts the given string to title case.
+//	join:  joins a list of strings with the given separator.
 func NewFormatter(textTemplate, htmlTemplate string, colors, icons map[string]string) *Formatter {
+	f, err := ParseFormatter(textTemplate, htmlTemplate, colors, icons)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// ParseFormatter creates a new formatter like NewFormatter,
+// but returns an error if a template cannot be parsed or executed.
+func ParseFormatter(textTemplate, htmlTemplate string, colors, icons map[string]string) (*Formatter, error) {
 	if textTemplate == "" {
 		textTemplate = DefaultTextTemplate
 	}
@@ -77,18 +131,163 @@ func NewFormatter(textTemplate, htmlTemplate string, colors, icons map[string]st
 		icons = DefaultIcons
 	}
 
+	var err error
+
 	f := &Formatter{colors: colors, icons: icons}
-	funcMap := map[string]interface{}{
+	f.funcMap = map[string]interface{}{
 		"icon":  f.icon,
 		"color": f.color,
 		"upper": strings.ToUpper,
 		"lower": strings.ToLower,
 		"title": strings.ToTitle,
+		"join":  strings.Join,
 	}
-	f.text = text.Must(text.New("").Funcs(funcMap).Parse(textTemplate))
-	f.html = html.Must(html.New("").Funcs(funcMap).Parse(htmlTemplate))
 
-	return f
+	f.text, err = text.New("").Funcs(f.funcMap).Parse(textTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing text template: %w", err)
+	}
+
+	f.html, err = html.New("").Funcs(f.funcMap).Parse(htmlTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML template: %w", err)
+	}
+
+	if err = f.validate(); err != nil {
+		return nil, err
+	}
+
+	if err = f.ParseListTemplates("", ""); err != nil {
+		return nil, err
+	}
+
+	if err = f.ParseDetailTemplates("", ""); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ParseListTemplates replaces the text/HTML templates used for summarising alert lists.
+// The templates receive an AlertList.
+// The default templates are used if "" is provided.
+// An error is returned if a template cannot be parsed or executed,
+// in which case the templates are not changed.
+func (f *Formatter) ParseListTemplates(textTemplate, htmlTemplate string) error {
+	if textTemplate == "" {
+		textTemplate = DefaultListTextTemplate
+	}
+
+	if htmlTemplate == "" {
+		htmlTemplate = DefaultListHTMLTemplate
+	}
+
+	list := NewAlertList([]*alertmanager.Alert{exampleAlert()}, "")
+
+	listText, listHTML, err := f.parseTemplates("list", textTemplate, htmlTemplate, list)
+	if err != nil {
+		return err
+	}
+
+	f.listText, f.listHTML = listText, listHTML
+
+	return nil
+}
+
+// ParseDetailTemplates replaces the text/HTML templates used for showing the details of an alert.
+// The templates receive an AlertDetails.
+// The default templates are used if "" is provided.
+// An error is returned if a template cannot be parsed or executed,
+// in which case the templates are not changed.
+func (f *Formatter) ParseDetailTemplates(textTemplate, htmlTemplate string) error {
+	if textTemplate == "" {
+		textTemplate = DefaultDetailTextTemplate
+	}
+
+	if htmlTemplate == "" {
+		htmlTemplate = DefaultDetailHTMLTemplate
+	}
+
+	details := &AlertDetails{
+		Alert:           exampleAlert(),
+		AlertmanagerURL: "http://localhost:9093",
+		AlertURL:        "http://localhost:9093/#/alerts",
+	}
+	details.Receivers = []string{"matrix"}
+	details.SilencedBy = []string{"00000000-0000-0000-0000-000000000000"}
+
+	detailText, detailHTML, err := f.parseTemplates("detail", textTemplate, htmlTemplate, details)
+	if err != nil {
+		return err
+	}
+
+	f.detailText, f.detailHTML = detailText, detailHTML
+
+	return nil
+}
+
+// parseTemplates parses a text and HTML template and executes them with the given data to detect errors.
+func (f *Formatter) parseTemplates(kind, textTemplate, htmlTemplate string, data interface{},
+) (*text.Template, *html.Template, error) {
+	textTmpl, err := text.New("").Funcs(f.funcMap).Parse(textTemplate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s text template: %w", kind, err)
+	}
+
+	htmlTmpl, err := html.New("").Funcs(f.funcMap).Parse(htmlTemplate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s HTML template: %w", kind, err)
+	}
+
+	if err = textTmpl.Execute(io.Discard, data); err != nil {
+		return nil, nil, fmt.Errorf("error executing %s text template: %w", kind, err)
+	}
+
+	if err = htmlTmpl.Execute(io.Discard, data); err != nil {
+		return nil, nil, fmt.Errorf("error executing %s HTML template: %w", kind, err)
+	}
+
+	return textTmpl, htmlTmpl, nil
+}
+
+// exampleAlert returns an alert for validating templates.
+func exampleAlert() *alertmanager.Alert {
+	return &alertmanager.Alert{
+		Status:       "firing",
+		Labels:       map[string]string{"alertname": "Example", "severity": "warning"},
+		Annotations:  map[string]string{"summary": "Example alert"},
+		StartsAt:     time.Now().Add(-time.Hour),
+		GeneratorURL: "http://localhost:9090/graph",
+		Fingerprint:  "0123456789abcdef",
+	}
+}
+
+// validate executes the templates with an example alert to detect errors.
+func (f *Formatter) validate() error {
+	alert := exampleAlert()
+	message := &Message{
+		Message: alertmanager.Message{
+			Version:      "4",
+			GroupKey:     "{}:{alertname=\"Example\"}",
+			Status:       "firing",
+			Receiver:     "matrix",
+			GroupLabels:  map[string]string{"alertname": "Example"},
+			CommonLabels: alert.Labels,
+			ExternalURL:  "http://localhost:9093",
+		},
+		Alerts:     []*alertmanager.Alert{alert},
+		ShowLabels: true,
+	}
+
+	if err := f.text.Execute(io.Discard, message); err != nil {
+		return fmt.Errorf("error executing text template: %w", err)
+	}
+
+	if err := f.html.Execute(io.Discard, message); err != nil {
+		return fmt.Errorf("error executing HTML template: %w", err)
+	}
+
+	return nil
 }
 
 // icon returns the icon for a string.
@@ -111,15 +310,40 @@ func (f *Formatter) color(t string) string {
 
 // FormatAlerts formats alerts as plain text and HTML.
 func (f *Formatter) FormatAlerts(alerts []*alertmanager.Alert, labels bool) (string, string) {
-	var plain, html strings.Builder
+	return f.format(&Message{Alerts: alerts, ShowLabels: labels})
+}
+
+// FormatMessage formats the alerts in a webhook message as plain text and HTML.
+// The fields of the webhook message are available in the templates.
+func (f *Formatter) FormatMessage(message *alertmanager.Message, labels bool) (string, string) {
+	return f.format(&Message{Message: *message, Alerts: message.Alerts, ShowLabels: labels})
+}
+
+// FormatAlertList formats a summary of a list of alerts as plain text and HTML.
+func (f *Formatter) FormatAlertList(list *AlertList) (string, string) {
+	return execute(f.listText, f.listHTML, list)
+}
 
-	message := &Message{Alerts: alerts, ShowLabels: labels}
+// FormatAlertDetails formats the details of an alert as plain text and HTML.
+func (f *Formatter) FormatAlertDetails(details *AlertDetails) (string, string) {
+	return execute(f.detailText, f.detailHTML, details)
+}
+
+// format formats a message as plain text and HTML.
+func (f *Formatter) format(message *Message) (string, string) {
+	return execute(f.text, f.html, message)
+}
+
+// execute executes a text and HTML template with the given data.
+// The error is returned as the result if a template cannot be executed.
+func execute(textTmpl *text.Template, htmlTmpl *html.Template, data interface{}) (string, string) {
+	var plain, html strings.Builder
 
-	if err := f.text.Execute(&plain, message); err != nil {
+	if err := textTmpl.Execute(&plain, data); err != nil {
 		return err.Error(), err.Error()
 	}
 
-	if err := f.html.Execute(&html, message); err != nil {
+	if err := htmlTmpl.Execute(&html, data); err != nil {
 		return err.Error(), err.Error()
 	}
 
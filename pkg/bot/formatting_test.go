@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+var updateGolden = flag.Bool("update", false, "update the expected output in testdata") //nolint:gochecknoglobals
+
+// testMessages returns the webhook payloads in testdata by name.
+func testMessages(t *testing.T) map[string]*alertmanager.Message {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("Error listing test payloads: %s", err)
+	}
+
+	if len(files) == 0 {
+		t.Fatal("No test payloads in testdata")
+	}
+
+	messages := make(map[string]*alertmanager.Message, len(files))
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Error reading %s: %s", file, err)
+		}
+
+		message := new(alertmanager.Message)
+		if err = json.Unmarshal(data, message); err != nil {
+			t.Fatalf("Error decoding %s: %s", file, err)
+		}
+
+		messages[strings.TrimSuffix(file, ".json")] = message
+	}
+
+	return messages
+}
+
+// assertGolden compares the output with the expected output in a file.
+// The file is replaced if the test is run with `-update`.
+func assertGolden(t *testing.T, file, output string) {
+	t.Helper()
+
+	if *updateGolden {
+		if err := os.WriteFile(file, []byte(output), 0o600); err != nil {
+			t.Fatalf("Error writing %s: %s", file, err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Error reading %s: %s", file, err)
+	}
+
+	if output != string(expected) {
+		t.Errorf("Unexpected output for %s:\nexpected: %q\ngot:      %q", file, expected, output)
+	}
+}
+
+func TestMessageDecode(t *testing.T) {
+	for name, message := range testMessages(t) {
+		if message.Version != "4" {
+			t.Errorf("%s: expected version 4, got %q", name, message.Version)
+		}
+
+		if message.GroupKey == "" || message.ExternalURL == "" || len(message.GroupLabels) == 0 {
+			t.Errorf("%s: missing message fields: %+v", name, message)
+		}
+
+		for _, alert := range message.Alerts {
+			if alert.Fingerprint == "" || alert.GeneratorURL == "" || alert.StartsAt.IsZero() {
+				t.Errorf("%s: missing alert fields: %+v", name, alert)
+			}
+
+			if alert.Status == "resolved" && alert.EndsAt.IsZero() {
+				t.Errorf("%s: missing end of resolved alert: %+v", name, alert)
+			}
+		}
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	formatter := NewFormatter("", "", nil, nil)
+
+	for name, message := range testMessages(t) {
+		plain, html := formatter.FormatMessage(message, false)
+
+		assertGolden(t, name+".txt", plain)
+		assertGolden(t, name+".html", html)
+	}
+}
+
+func TestFormatMessageTemplate(t *testing.T) {
+	text, err := os.ReadFile(filepath.Join("testdata", "custom.txt.tmpl"))
+	if err != nil {
+		t.Fatalf("Error reading template: %s", err)
+	}
+
+	html, err := os.ReadFile(filepath.Join("testdata", "custom.html.tmpl"))
+	if err != nil {
+		t.Fatalf("Error reading template: %s", err)
+	}
+
+	formatter, err := ParseFormatter(string(text), string(html), nil, nil)
+	if err != nil {
+		t.Fatalf("Error parsing templates: %s", err)
+	}
+
+	for name, message := range testMessages(t) {
+		plain, html := formatter.FormatMessage(message, false)
+
+		assertGolden(t, name+".custom.txt", plain)
+		assertGolden(t, name+".custom.html", html)
+	}
+}
+
+func TestFormatMessageCompatibility(t *testing.T) {
+	text := `{{ range .Alerts }}{{ .ExtendedAlert.Fingerprint }}{{ range $k, $v := .Alert.Labels }} {{ $k }}={{ $v }}{{ end }}` +
+		` silenced by {{ join .ExtendedAlert.Status.SilencedBy "," }}{{ end }}`
+
+	formatter, err := ParseFormatter(text, "", nil, nil)
+	if err != nil {
+		t.Fatalf("Error parsing templates: %s", err)
+	}
+
+	message := decodeMessage(t, `{"status":"firing","alerts":[{"status":"firing","fingerprint":"0001",
+		"labels":{"alertname":"Test","job":"a"}}]}`)
+	message.Alerts[0].SilencedBy = []string{"s1", "s2"}
+
+	expected := "0001 alertname=Test job=a silenced by s1,s2"
+	if plain, _ := formatter.FormatMessage(message, false); plain != expected {
+		t.Errorf("Expected %q, got %q", expected, plain)
+	}
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// testHandlers contains handlers for requests to a test server, by a part of the path of the requests they handle.
+// The paths must not contain each other.
+type testHandlers map[string]http.HandlerFunc
+
+// respond returns a handler that responds with the given body.
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// newTestClient returns a client using a test server as homeserver and Alertmanager.
+// The server responds to requests using the given handlers, and with 404 Not Found to any other request.
+func newTestClient(t *testing.T, handlers testHandlers, config *ClientConfig) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for path, handler := range handlers {
+			if strings.Contains(r.URL.Path, path) {
+				handler(w, r)
+
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	if config == nil {
+		config = new(ClientConfig)
+	}
+
+	config.Homeserver = server.URL
+	config.UserID = "@bot:example.com"
+	config.Token = "token"
+	config.AlertManagerURL = server.URL
+
+	client, err := NewClient(config, nil)
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+
+	return client
+}
+
+// decodeMessage returns the message decoded from the given webhook payload.
+func decodeMessage(t *testing.T, payload string) *alertmanager.Message {
+	t.Helper()
+
+	var message *alertmanager.Message
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		t.Fatalf("Error decoding message: %s", err)
+	}
+
+	return message
+}
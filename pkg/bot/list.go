@@ -0,0 +1,383 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Options of the `list` command.
+const (
+	receiverOption    = "--receiver"
+	inhibitedOption   = "--inhibited"
+	unprocessedOption = "--unprocessed"
+	groupByOption     = "--group-by"
+	fullOption        = "--full"
+	pageArgument      = "page"
+)
+
+// Alert list settings.
+const (
+	listPageSize   = 20          // Number of alerts shown per page.
+	defaultGroupBy = "alertname" // Default label for grouping alert lists.
+)
+
+var (
+	errInvalidPage = errors.New("invalid page")
+	errMissingPage = errors.New("missing page number")
+)
+
+// AlertList represents a summary of a list of alerts, grouped by a label.
+// It is used for formatting.
+type AlertList struct {
+	Alerts  []*alertmanager.Alert // All alerts in the list.
+	Groups  []*AlertListGroup     // Groups of alerts, largest first.
+	GroupBy string                // Label the alerts are grouped by.
+	Total   int                   // Total number of alerts.
+}
+
+// AlertListGroup represents a group of alerts with the same value for the grouping label.
+type AlertListGroup struct {
+	Name   string                // Value of the grouping label.
+	Alerts []*alertmanager.Alert // Alerts in the group.
+	Counts []*StatusCount        // Number of alerts per status, largest first.
+	Total  int                   // Total number of alerts in the group.
+}
+
+// StatusCount represents the number of alerts with a status, see alertmanager.Alert.StatusString.
+type StatusCount struct {
+	Status string
+	Count  int
+}
+
+// NewAlertList groups alerts by the given label.
+// Alerts are grouped by `alertname` if the label is empty.
+func NewAlertList(alerts []*alertmanager.Alert, groupBy string) *AlertList {
+	if groupBy == "" {
+		groupBy = defaultGroupBy
+	}
+
+	list := &AlertList{Alerts: alerts, GroupBy: groupBy, Total: len(alerts)}
+	groups := make(map[string]*AlertListGroup)
+
+	for _, a := range alerts {
+		name := a.Labels[groupBy]
+
+		group, ok := groups[name]
+		if !ok {
+			group = &AlertListGroup{Name: name}
+			groups[name] = group
+			list.Groups = append(list.Groups, group)
+		}
+
+		group.Alerts = append(group.Alerts, a)
+		group.Total++
+		group.addStatus(a.StatusString())
+	}
+
+	sort.SliceStable(list.Groups, func(i, j int) bool {
+		if list.Groups[i].Total != list.Groups[j].Total {
+			return list.Groups[i].Total > list.Groups[j].Total
+		}
+
+		return list.Groups[i].Name < list.Groups[j].Name
+	})
+
+	for _, g := range list.Groups {
+		sort.SliceStable(g.Counts, func(i, j int) bool {
+			if g.Counts[i].Count != g.Counts[j].Count {
+				return g.Counts[i].Count > g.Counts[j].Count
+			}
+
+			return g.Counts[i].Status < g.Counts[j].Status
+		})
+	}
+
+	return list
+}
+
+// addStatus increments the count of alerts with the given status.
+func (g *AlertListGroup) addStatus(status string) {
+	for _, c := range g.Counts {
+		if c.Status == status {
+			c.Count++
+			return
+		}
+	}
+
+	g.Counts = append(g.Counts, &StatusCount{Status: status, Count: 1})
+}
+
+// listOptions contains the options of the `list` command.
+type listOptions struct {
+	filter  *alertmanager.AlertFilter
+	groupBy string
+	full    bool
+	page    int
+	command []string // Command and arguments without the page, for referring to other pages.
+}
+
+// listDescription is the description of the `list` command.
+const listDescription = "Show active alerts, optionally filtered by `matchers`.\n" +
+	"A summary is shown if more alerts are firing than fit on a page.\n\n" +
+	"The following options are supported:\n\n" +
+	"- `--receiver=<regex>`: only show alerts for matching receivers.\n" +
+	"- `--inhibited`: also show inhibited alerts.\n" +
+	"- `--unprocessed`: also show alerts that have not been processed yet.\n" +
+	"- `--group-by=<label>`: group the summary by a label instead of `alertname`.\n" +
+	"- `--full`: show all alerts instead of a summary.\n" +
+	"- `page <n>`: show a page of all alerts.\n" +
+	"- `--am=<name>`: only show alerts of a single Alertmanager.\n\n" +
+	"Option values can also be separated by a space, eg: `--receiver ops`, and quoted if they contain spaces.\n" +
+	"For example:\n" +
+	"```\nlist all severity=\"critical\",team=~\"db.*\" --receiver=ops\n```\n"
+
+// mainCommand returns the `alert` bot command.
+func (c *Client) listOnlyCommand() *bot.Command {
+	return &bot.Command{
+		Summary:        "Show active alerts.",
+		MessageHandler: c.listHandler("", false, false),
+	}
+}
+
+// listCommand returns the `list` bot command.
+func (c *Client) listCommand() *bot.Command {
+	cmd := c.listOnlyCommand()
+	cmd.Description = listDescription
+	cmd.MessageHandler = c.listHandler("list", false, false)
+	cmd.Subcommands = map[string]*bot.Command{
+		"all": {
+			Summary:        "Show active and silenced alerts.",
+			MessageHandler: c.listHandler("list all", true, false),
+			Subcommands: map[string]*bot.Command{
+				"labels": {
+					Summary:        "Shows label of active and silenced alerts.",
+					MessageHandler: c.listHandler("list all labels", true, true),
+				},
+			},
+		},
+		"labels": {
+			Summary:        "Show labels of active alerts.",
+			MessageHandler: c.listHandler("list labels", false, true),
+		},
+	}
+
+	return cmd
+}
+
+// listHandler returns a handler for the `list` command or subcommand with the given path.
+func (c *Client) listHandler(path string, silenced, labels bool) func(sender, cmd string, args ...string) *bot.Message {
+	return c.handler(path, func(sender string, args []string) (*bot.Message, error) {
+		am, args := alertmanagerArg(args)
+
+		opts, err := parseListOptions(args)
+		if err != nil {
+			return errorMessage(err)
+		}
+
+		opts.filter.Silenced = silenced
+		opts.command = listCommandArgs(path, am, args)
+
+		return c.alertList(am, opts, labels)
+	})
+}
+
+// listCommandArgs returns the command with the given path and arguments, without the page argument.
+func listCommandArgs(path, am string, args []string) []string {
+	if path == "" {
+		path = "list"
+	}
+
+	command := strings.Fields(path)
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == pageArgument {
+			i++
+			continue
+		}
+
+		command = append(command, args[i])
+	}
+
+	if am != "" {
+		command = append(command, alertmanagerOption+"="+am)
+	}
+
+	return command
+}
+
+// parseListOptions parses the matchers and options of the `list` command.
+// The filter only includes active alerts by default.
+func parseListOptions(args []string) (*listOptions, error) {
+	parsed, err := parseArgs(args,
+		[]string{receiverOption, groupByOption},
+		[]string{inhibitedOption, unprocessedOption, fullOption})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &listOptions{
+		filter: &alertmanager.AlertFilter{
+			Active:      true,
+			Receiver:    parsed.options[receiverOption],
+			Inhibited:   parsed.flags[inhibitedOption],
+			Unprocessed: parsed.flags[unprocessedOption],
+		},
+		groupBy: parsed.options[groupByOption],
+		full:    parsed.flags[fullOption],
+	}
+
+	args = parsed.args
+	matchers := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == pageArgument:
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("%w, usage: list %s <n>", errMissingPage, pageArgument)
+			}
+
+			page, err := strconv.Atoi(args[i+1])
+			if err != nil || page < 1 {
+				return nil, fmt.Errorf("%w: %q", errInvalidPage, args[i+1])
+			}
+
+			opts.page = page
+			i++
+		case arg != "":
+			matchers = append(matchers, arg)
+		}
+	}
+
+	if len(matchers) == 0 {
+		return opts, nil
+	}
+
+	parsedMatchers, err := labels.ParseMatchers(strings.Join(matchers, " "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid matchers: %w", err)
+	}
+
+	for _, m := range parsedMatchers {
+		opts.filter.Matchers = append(opts.filter.Matchers, m.String())
+	}
+
+	return opts, nil
+}
+
+// alertList returns the alerts of the given Alertmanager matching the filter
+// as a summary if there are more alerts than fit on a page, or as a page of the full list.
+// An error is returned if the alerts of one or more Alertmanagers could not be retrieved.
+func (c *Client) alertList(am string, opts *listOptions, labels bool) (*bot.Message, error) {
+	alerts, errs, err := c.alerts(am, opts.filter)
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	if len(alerts) == 0 {
+		return bot.NewTextMessage(strings.Join(append(errs, "No alerts"), "\n")), commandError(errs)
+	}
+
+	if len(alerts) <= listPageSize && opts.page <= 1 {
+		plain, formatted := c.formatter().FormatAlerts(alerts, labels)
+
+		return withErrors(errs, plain, formatted), commandError(errs)
+	}
+
+	if !opts.full && opts.page == 0 {
+		plain, formatted := c.formatter().FormatAlertList(NewAlertList(alerts, opts.groupBy))
+
+		return withErrors(errs, plain, formatted), commandError(errs)
+	}
+
+	page := opts.page
+	if page == 0 {
+		page = 1
+	}
+
+	pages := (len(alerts) + listPageSize - 1) / listPageSize
+	if page > pages {
+		return errorMessage(fmt.Errorf("%w: %d, there are %d pages", errInvalidPage, page, pages))
+	}
+
+	end := page * listPageSize
+	if end > len(alerts) {
+		end = len(alerts)
+	}
+
+	plain, formatted := c.formatter().FormatAlerts(alerts[(page-1)*listPageSize:end], labels)
+
+	footer := fmt.Sprintf("Page %d of %d", page, pages)
+	if page < pages {
+		footer += fmt.Sprintf(", use `%s %s %d` for more", strings.Join(opts.command, " "), pageArgument, page+1)
+	}
+
+	return withErrors(errs, plain+footer+"\n", formatted+html.EscapeString(footer)+"<br/>"), commandError(errs)
+}
+
+// alerts retrieves the alerts of the given Alertmanager matching the filter, sorted by name.
+// The alerts of all selected Alertmanagers are combined if the name is empty or `all`,
+// and errors of the individual Alertmanagers are returned as messages.
+func (c *Client) alerts(am string, filter *alertmanager.AlertFilter) ([]*alertmanager.Alert, []string, error) {
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		alerts []*alertmanager.Alert
+		errs   []string
+	)
+
+	for _, name := range names {
+		amAlerts, err := c.Alertmanagers[name].Alert.List(context.TODO(), filter)
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, fmt.Errorf("error retrieving alerts from alertmanager: %w", err)))
+			continue
+		}
+
+		if err = c.annotateAcks(context.TODO(), c.Alertmanagers[name], amAlerts); err != nil {
+			log.Printf("Error retrieving acknowledgements: %s", err)
+		}
+
+		if c.multipleAlertmanagers() {
+			for _, a := range amAlerts {
+				a.Alertmanager = name
+			}
+		}
+
+		alerts = append(alerts, amAlerts...)
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].AlertName() != alerts[j].AlertName() {
+			return alerts[i].AlertName() < alerts[j].AlertName()
+		}
+
+		return alerts[i].Fingerprint < alerts[j].Fingerprint
+	})
+
+	return alerts, errs, nil
+}
+
+// withErrors returns an HTML message with the given errors in front of the formatted text.
+func withErrors(errs []string, plain, formatted string) *bot.Message {
+	for i := len(errs) - 1; i >= 0; i-- {
+		plain = errs[i] + "\n" + plain
+		formatted = html.EscapeString(errs[i]) + "<br/>" + formatted
+	}
+
+	return bot.NewHTMLMessage(plain, formatted)
+}
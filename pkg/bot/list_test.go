@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+func TestListCommandArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		am      string
+		args    []string
+		command string
+	}{
+		{name: "bare command", path: "", command: "list"},
+		{name: "subcommand", path: "list all labels", command: "list all labels"},
+		{name: "page", path: "list", args: []string{"page", "2"}, command: "list"},
+		{
+			name:    "options",
+			path:    "list all",
+			am:      "prod",
+			args:    []string{`job="a"`, "--receiver", `"ops team"`, "page", "3", "--group-by=team", "--full"},
+			command: `list all job="a" --receiver "ops team" --group-by=team --full --am=prod`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			command := strings.Join(listCommandArgs(test.path, test.am, test.args), " ")
+			if command != test.command {
+				t.Errorf("Expected %q, got %q", test.command, command)
+			}
+		})
+	}
+}
+
+func TestListPageHint(t *testing.T) {
+	alerts := make([]string, listPageSize+5)
+	for i := range alerts {
+		alerts[i] = fmt.Sprintf(`{"labels":{"alertname":"Test","job":"a"},"fingerprint":"%04d",`+
+			`"status":{"state":"active"}}`, i)
+	}
+
+	client := newTestClient(t, testHandlers{"/api/v2/alerts": respond("[" + strings.Join(alerts, ",") + "]")}, nil)
+
+	msg, err := client.handlers["list"]("@user:example.com", []string{`job="a"`, "--receiver", "ops", "--full"})
+	if err != nil {
+		t.Fatalf("Error listing alerts: %s", err)
+	}
+
+	if hint := "use `list job=\"a\" --receiver ops --full page 2` for more"; !strings.Contains(msg.Body, hint) {
+		t.Errorf("Expected the message to contain %q, got:\n%s", hint, msg.Body)
+	}
+}
+
+func TestParseListOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		filter alertmanager.AlertFilter
+	}{
+		{name: "default", filter: alertmanager.AlertFilter{Active: true}},
+		{
+			name:   "unprocessed",
+			args:   []string{"--unprocessed"},
+			filter: alertmanager.AlertFilter{Active: true, Unprocessed: true},
+		},
+		{name: "inhibited", args: []string{"--inhibited"}, filter: alertmanager.AlertFilter{Active: true, Inhibited: true}},
+		{
+			name:   "receiver",
+			args:   []string{"--receiver", "ops"},
+			filter: alertmanager.AlertFilter{Active: true, Receiver: "ops"},
+		},
+		{
+			name:   "matchers",
+			args:   []string{`severity="critical",team=~"db.*"`},
+			filter: alertmanager.AlertFilter{Active: true, Matchers: []string{`severity="critical"`, `team=~"db.*"`}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := parseListOptions(test.args)
+			if err != nil {
+				t.Fatalf("Error parsing options: %s", err)
+			}
+
+			if !reflect.DeepEqual(*opts.filter, test.filter) {
+				t.Errorf("Expected filter %+v, got %+v", test.filter, *opts.filter)
+			}
+		})
+	}
+}
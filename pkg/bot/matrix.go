@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/alertmanager/pkg/labels"
@@ -15,7 +16,22 @@ import (
 	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
 )
 
-var errNilClientConfig = errors.New("client config cannot be nil")
+// silenceComment is the default comment of silences created from Matrix.
+const silenceComment = "Created from Matrix"
+
+// Options of the `silence add` command.
+const (
+	commentOption = "--comment"
+	atOption      = "--at"
+	confirmOption = "--confirm"
+)
+
+var (
+	errNilClientConfig = errors.New("client config cannot be nil")
+	errNoAlert         = errors.New("no alert with fingerprint")
+	errNoSilence       = errors.New("silence not found in any alertmanager")
+	errSilenceEnded    = errors.New("silence would end in the past")
+)
 
 // ClientConfig contains the configuration for the client.
 type ClientConfig struct {
@@ -24,14 +40,78 @@ type ClientConfig struct {
 	Token           string // Matrix token.
 	MessageType     string // Matrix NewMessage type (optional).
 	Rooms           string // Comma-separated list of matrix rooms (optional).
-	AlertManagerURL string // URL to the Alert Manager API.
+	AlertManagerURL string // Comma-separated list of URLs to the Alert Manager API of each replica.
+
+	// AlertManagerHTTPConfig contains the HTTP configuration for the Alertmanager API (optional).
+	AlertManagerHTTPConfig *alertmanager.HTTPConfig
+
+	// Alertmanagers contains named Alertmanager instances or clusters (optional).
+	// AlertManagerURL and AlertManagerHTTPConfig are ignored if any are configured.
+	Alertmanagers map[string]*AlertmanagerConfig
+
+	// DefaultAlertmanager is the name of the Alertmanager used by commands (optional).
+	// It can be overridden per room using RoomConfigs.
+	// Commands use all Alertmanagers if it is not set.
+	DefaultAlertmanager string
+
+	StateFile               string     // File for persisting the state of sent messages (optional).
+	SilenceReaction         string     // Reaction for silencing the alerts in a message (optional).
+	SilenceDuration         string     // Duration of silences created by reactions.
+	SilenceConfirmThreshold int        // Number of alerts a new silence can match without confirmation (optional).
+	AckDuration             string     // Duration of acknowledgements (optional).
+	ShowLabels              bool       // Show labels in alert messages.
+	NotifyMode              NotifyMode // Notification mode for alert groups (optional).
+	QueueSize               int        // Maximum number of notifications queued for retrying (optional).
+	RetryMaxAge             string     // Duration after which queued notifications are dropped (optional).
+	DedupWindow             string     // Duration in which identical notifications are ignored (optional).
+
+	// WebhookAuth contains the credentials required for webhooks (optional).
+	// They can be overridden per room using RoomConfigs.
+	WebhookAuth *WebhookAuth
+
+	// Confirm contains the commands that require confirmation before they are executed (optional).
+	Confirm *ConfirmConfig
+
+	// RoomConfigs contains the configuration per room ID or alias (optional).
+	// These rooms are joined, and added to the list of allowed rooms if Rooms is set.
+	RoomConfigs map[string]*RoomConfig
+
+	// Routes contains the routes for alerts, in order of evaluation (optional).
+	// The rooms are joined, and added to the list of allowed rooms if Rooms is set.
+	Routes []*Route
 }
 
 // Client represents an Alertmanager/Matrix client.
 type Client struct {
-	Matrix       *bot.Client
-	Alertmanager *alertmanager.Client
-	Formatter    *Formatter
+	Matrix        *bot.Client
+	Alertmanagers map[string]*alertmanager.Client // Alertmanager clients by name.
+	Formatter     *Formatter                      // Formatter for messages. Use Reload to replace it after the client is created.
+
+	store               *store
+	defaultAlertmanager string
+	groupLocks          keyLock    // Locks for sending notifications for each alert group.
+	queueMu             sync.Mutex // Lock for the retry queue.
+	dedupMu             sync.Mutex // Lock for the deduplication state.
+	queueSize           int
+	queueWake           chan struct{}
+	retryMaxAge         time.Duration
+	dedupWindow         time.Duration
+	silenceReaction     string
+	silenceDuration     string
+	confirmCommands     map[string]int
+	confirmTimeout      time.Duration
+	confirmations       map[string]*confirmation
+	confirmMu           sync.Mutex
+	ackDuration         string
+	showLabels          bool
+	notifyMode          NotifyMode
+	webhookAuth         *WebhookAuth
+	rooms               map[string]*RoomConfig
+	joinList            []string
+	handlers            map[string]handlerFunc
+	routes              []*route
+	aliases             map[string]*alias
+	configMu            sync.RWMutex
 }
 
 // NewClient creates and starts a new Alertmanager/Matrix client.
@@ -41,7 +121,81 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 	}
 
 	client = &Client{
-		Formatter: formatter,
+		handlers:        make(map[string]handlerFunc),
+		Formatter:       formatter,
+		silenceReaction: config.SilenceReaction,
+		silenceDuration: config.SilenceDuration,
+		ackDuration:     config.AckDuration,
+		showLabels:      config.ShowLabels,
+		notifyMode:      config.NotifyMode,
+		webhookAuth:     config.WebhookAuth,
+		aliases:         make(map[string]*alias),
+		queueSize:       config.QueueSize,
+		queueWake:       make(chan struct{}, 1),
+	}
+
+	// Validate the notification modes
+	client.notifyMode, err = ParseNotifyMode(string(client.notifyMode))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Alertmanager clients
+	client.Alertmanagers, err = newAlertmanagers(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client.defaultAlertmanager = config.DefaultAlertmanager
+	if err = client.validateAlertmanager(client.defaultAlertmanager); err != nil {
+		return nil, fmt.Errorf("invalid default alertmanager: %w", err)
+	}
+
+	client.rooms, err = client.validateRoomConfigs(config.RoomConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	client.routes, err = validateRoutes(config.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the acknowledgement duration
+	if client.ackDuration == "" {
+		client.ackDuration = defaultAckDuration
+	}
+
+	if _, err = parseDuration(client.ackDuration); err != nil {
+		return nil, fmt.Errorf("invalid acknowledgement duration: %w", err)
+	}
+
+	// Validate the retry queue configuration
+	if client.queueSize <= 0 {
+		client.queueSize = defaultQueueSize
+	}
+
+	retryMaxAge := config.RetryMaxAge
+	if retryMaxAge == "" {
+		retryMaxAge = defaultRetryMaxAge
+	}
+
+	if client.retryMaxAge, err = parseDuration(retryMaxAge); err != nil {
+		return nil, fmt.Errorf("invalid maximum retry age: %w", err)
+	}
+
+	// Validate the deduplication window
+	if config.DedupWindow != "" {
+		if client.dedupWindow, err = parseDuration(config.DedupWindow); err != nil {
+			return nil, fmt.Errorf("invalid deduplication window: %w", err)
+		}
+	}
+
+	// Validate the silence duration
+	if client.silenceReaction != "" {
+		if _, err = parseDuration(client.silenceDuration); err != nil {
+			return nil, fmt.Errorf("invalid silence duration: %w", err)
+		}
 	}
 
 	// Ensure a formatter is set
@@ -49,16 +203,19 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 		client.Formatter = NewFormatter("", "", nil, nil)
 	}
 
-	// Create Alertmanager client
-	client.Alertmanager, err = alertmanager.NewClient(config.AlertManagerURL)
+	// Load the message state
+	client.store, err = newStore(config.StateFile)
 	if err != nil {
 		return
 	}
 
-	// Matrix bot config
+	queueLength.Set(float64(len(client.store.All(queueBucket))))
+
+	// Matrix bot config.
+	// Commands are handled by the client itself, using the prefixes in this configuration, longest first.
 	matrixConfig := &bot.ClientConfig{
 		MessageType:      config.MessageType,
-		CommandPrefixes:  []string{"!alert", "!alertmanager"},
+		CommandPrefixes:  []string{"!alertmanager", "!alert"},
 		IgnoreHighlights: false,
 	}
 
@@ -68,77 +225,82 @@ func NewClient(config *ClientConfig, formatter *Formatter) (client *Client, err
 		return
 	}
 
-	// Create room list
+	// Resolve the aliases of configured rooms, so that their settings apply before the rooms are joined
+	if err = client.resolveRoomConfigs(); err != nil {
+		return nil, err
+	}
+
+	// Create room list.
+	// Configured rooms are joined, and allowed if commands are limited to a list of rooms.
 	if config.Rooms != "" {
 		matrixConfig.AllowedRooms = strings.Split(config.Rooms, ",")
 	}
 
+	for _, id := range configuredRooms(config) {
+		if !contains(client.joinList, id) {
+			client.joinList = append(client.joinList, id)
+		}
+
+		if len(matrixConfig.AllowedRooms) > 0 && !contains(matrixConfig.AllowedRooms, id) {
+			matrixConfig.AllowedRooms = append(matrixConfig.AllowedRooms, id)
+		}
+	}
+
 	// Register commands
 	client.Matrix.SetCommand("", client.listOnlyCommand())
 	client.Matrix.SetCommand("list", client.listCommand())
 	client.Matrix.SetCommand("silence", client.silenceCommand())
+	client.Matrix.SetCommand("ack", client.ackCommand())
+	client.Matrix.SetCommand("show", client.showCommand())
+	client.Matrix.SetCommand("status", client.statusCommand())
 
-	return
-}
+	if err = client.validateConfirmConfig(config.Confirm, config.SilenceConfirmThreshold); err != nil {
+		return nil, err
+	}
 
-// mainCommand returns the `alert` bot command.
-func (c *Client) listOnlyCommand() *bot.Command {
-	return &bot.Command{
-		Summary: "Show active alerts.",
-		MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-			return c.Alerts(false, false)
-		},
+	// Register message handlers
+	client.Matrix.SetMessageHandler(bot.EventTypeRoomMessage, client.handleMessage)
+
+	// Register reaction handlers
+	if client.silenceReaction != "" || len(client.confirmCommands) > 0 {
+		client.Matrix.SetMessageHandler(eventTypeReaction, client.handleReaction)
 	}
-}
 
-// listCommand returns the `list` bot command.
-func (c *Client) listCommand() *bot.Command {
-	cmd := c.listOnlyCommand()
-	cmd.Subcommands = map[string]*bot.Command{
-		"all": {
-			Summary: "Show active and silenced alerts.",
-			MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-				return c.Alerts(true, false)
-			},
-			Subcommands: map[string]*bot.Command{
-				"labels": {
-					Summary: "Shows label of active and silenced alerts.",
-					MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-						return c.Alerts(true, true)
-					},
-				},
-			},
-		},
-		"labels": {
-			Summary: "Show labels of active alerts.",
-			MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-				return c.Alerts(false, true)
-			},
-		},
+	if client.silenceReaction != "" {
+		client.Matrix.SetMessageHandler(eventTypeRedaction, client.handleRedaction)
 	}
 
-	return cmd
+	return
 }
 
 // silenceCommand returns the `silence` command.
 func (c *Client) silenceCommand() *bot.Command {
 	return &bot.Command{
 		Summary: "Show active silences.",
-		MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-			return bot.NewMarkdownMessage(c.Silences("active"))
-		},
+		MessageHandler: c.handler("silence", func(sender string, args []string) (*bot.Message, error) {
+			am, _ := alertmanagerArg(args)
+			md, err := c.Silences(am, "active")
+
+			return bot.NewMarkdownMessage(md), err
+		}),
 		Subcommands: map[string]*bot.Command{
 			"pending": {
 				Summary: "Show pending silences.",
-				MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.Silences("pending"))
-				},
+				MessageHandler: c.handler("silence pending", func(sender string, args []string) (*bot.Message, error) {
+					am, _ := alertmanagerArg(args)
+					md, err := c.Silences(am, "pending")
+
+					return bot.NewMarkdownMessage(md), err
+				}),
 			},
 			"expired": {
 				Summary: "Shows expired silences.",
-				MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.Silences("expired"))
-				},
+				MessageHandler: c.handler("silence expired", func(sender string, args []string) (*bot.Message, error) {
+					am, _ := alertmanagerArg(args)
+					md, err := c.Silences(am, "expired")
+
+					return bot.NewMarkdownMessage(md), err
+				}),
 			},
 			"add": {
 				Summary: "Create a silence.",
@@ -146,20 +308,87 @@ func (c *Client) silenceCommand() *bot.Command {
 					"A matcher matches job labels, for example: \n" +
 					"```\nsilence add 1h job=\"test\",target=~\"test.*\"\n```\n" +
 					"Alternative, an alert fingerprint can be given to match all labels of that alert, for example:\n" +
-					"```\nsilence add 1h 04e45af092081699\n```\n",
-				MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-					if len(args) <= 1 {
-						return bot.NewTextMessage("Insufficient arguments.")
+					"```\nsilence add 1h 04e45af092081699\n```\n" +
+					"A comment can be given using `--comment`, " +
+					"and the silence can be scheduled using `--at` with a time or a duration from now, for example:\n" +
+					"```\nsilence add 2h --at 2026-11-01T22:00 --comment \"kernel upgrade\" job=\"node\"\n" +
+					"silence add 1h --at +30m job=\"node\"\n```\n" +
+					"The firing alerts matched by the silence are shown. " +
+					"If the silence matches too many alerts, " +
+					"it is only created when confirmed or when `--confirm` is given.\n\n" +
+					"The Alertmanager can be selected using `--am=<name>`, or `--am=all` for all Alertmanagers.\n",
+				MessageHandler: c.handler(silenceAddCommand, func(sender string, args []string) (*bot.Message, error) {
+					am, args := alertmanagerArg(args)
+
+					return c.silenceAdd(sender, am, args)
+				}),
+			},
+			"preview": {
+				Summary: "Show the alerts a silence would match.",
+				Description: "Show the firing alerts that a silence with the given `matcher` or `fingerprint` would match, " +
+					"without creating the silence, for example:\n" +
+					"```\nsilence preview job=\"test\",target=~\"test.*\"\n```\n",
+				MessageHandler: c.handler("silence preview", func(sender string, args []string) (*bot.Message, error) {
+					am, args := alertmanagerArg(args)
+					if len(args) == 0 {
+						return insufficientArgs()
 					}
 
-					return bot.NewMarkdownMessage(c.NewSilence(sender, args[0], strings.Join(args[1:], " ")))
-				},
+					return c.PreviewSilence(am, strings.Join(args, " "))
+				}),
 			},
 			"del": {
 				Summary: "Delete a silence by ID.",
-				MessageHandler: func(sender, cmd string, args ...string) *bot.Message {
-					return bot.NewMarkdownMessage(c.DelSilence(args))
-				},
+				MessageHandler: c.handler(silenceDelCommand, func(sender string, args []string) (*bot.Message, error) {
+					am, ids := alertmanagerArg(args)
+					md, err := c.DelSilence(am, ids)
+
+					return bot.NewMarkdownMessage(md), err
+				}),
+			},
+			"extend": {
+				Summary: "Extend a silence by a duration.",
+				Description: "Extend a silence by a `duration`, for example:\n" +
+					"```\nsilence extend 7f3c4c8e-3c5a-4b8e-9b0e-0a6f5c1b2d3e 2h\n```\n",
+				MessageHandler: c.handler("silence extend", func(sender string, args []string) (*bot.Message, error) {
+					am, args := alertmanagerArg(args)
+					if len(args) != 2 {
+						return insufficientArgs()
+					}
+
+					md, err := c.ExtendSilence(sender, am, args[0], args[1])
+
+					return bot.NewMarkdownMessage(md), err
+				}),
+			},
+			"edit": {
+				Summary: "Replace the matchers of a silence.",
+				Description: "Replace the matchers of a silence, for example:\n" +
+					"```\nsilence edit 7f3c4c8e-3c5a-4b8e-9b0e-0a6f5c1b2d3e job=\"test\",target=~\"test.*\"\n```\n" +
+					"The Alertmanager replaces the silence by a new one with a new ID.\n",
+				MessageHandler: c.handler("silence edit", func(sender string, args []string) (*bot.Message, error) {
+					am, args := alertmanagerArg(args)
+					if len(args) <= 1 {
+						return insufficientArgs()
+					}
+
+					md, err := c.EditSilence(sender, am, args[0], strings.Join(args[1:], " "))
+
+					return bot.NewMarkdownMessage(md), err
+				}),
+			},
+			"comment": {
+				Summary: "Add a comment to a silence.",
+				MessageHandler: c.handler("silence comment", func(sender string, args []string) (*bot.Message, error) {
+					am, args := alertmanagerArg(args)
+					if len(args) <= 1 {
+						return insufficientArgs()
+					}
+
+					md, err := c.CommentSilence(sender, am, args[0], strings.Join(args[1:], " "))
+
+					return bot.NewMarkdownMessage(md), err
+				}),
 			},
 		},
 	}
@@ -172,6 +401,14 @@ func (c *Client) Run() error {
 		return err
 	}
 
+	err = c.joinRooms(c.joinList)
+	if err != nil {
+		return err
+	}
+
+	go c.extendAcks()
+	go c.processQueue()
+
 	err = c.Matrix.Run()
 	if err != nil {
 		return fmt.Errorf("matrix error: %w", err)
@@ -180,7 +417,23 @@ func (c *Client) Run() error {
 	return nil
 }
 
+// configuredRooms returns the rooms in the room configuration and routes.
+func configuredRooms(config *ClientConfig) []string {
+	rooms := make([]string, 0, len(config.RoomConfigs))
+
+	for id := range config.RoomConfigs {
+		rooms = append(rooms, id)
+	}
+
+	for _, route := range config.Routes {
+		rooms = append(rooms, route.Rooms...)
+	}
+
+	return rooms
+}
+
 // joinRooms joins a list of room IDs or aliases.
+// Aliases in the list are replaced by room IDs.
 func (c *Client) joinRooms(roomList []string) error {
 	for i, r := range roomList {
 		id, err := c.Matrix.NewRoom(r).Join()
@@ -188,120 +441,270 @@ func (c *Client) joinRooms(roomList []string) error {
 			return fmt.Errorf("cannot join room %q: %w", r, err)
 		}
 
+		// The list is in use by webhooks and commands
+		c.configMu.Lock()
 		roomList[i] = id
+		c.configMu.Unlock()
+
+		if id != r {
+			c.cacheAlias(r, id)
+		}
 	}
 
 	return nil
 }
 
-// Alerts returns all or non-silenced alerts.
-func (c *Client) Alerts(silenced bool, labels bool) *bot.Message {
-	alerts, err := c.Alertmanager.GetAlerts(silenced)
+// Silences returns a Markdown formatted NewMessage containing silences with the specified state.
+// The silences of all selected Alertmanagers are combined if the name is empty or `all`.
+// An error is returned if the silences of one or more Alertmanagers could not be retrieved.
+func (c *Client) Silences(am, state string) (string, error) {
+	names, err := c.alertmanagers(am)
 	if err != nil {
-		return bot.NewTextMessage(err.Error())
+		return err.Error(), err
 	}
 
-	if len(alerts) == 0 {
-		return bot.NewTextMessage("No alerts")
+	var parts, errs []string
+
+	for _, name := range names {
+		silences, err := c.Alertmanagers[name].Silence.List(context.TODO())
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			parts = append(parts, c.alertmanagerError(name, err))
+
+			continue
+		}
+
+		md := c.formatter().FormatSilences(silences, state)
+		if md == "" {
+			continue
+		}
+
+		if c.multipleAlertmanagers() {
+			md = fmt.Sprintf("**Alertmanager %s**\n\n%s", name, md)
+		}
+
+		parts = append(parts, md)
 	}
 
-	return bot.NewHTMLMessage(c.Formatter.FormatAlerts(alerts, labels))
+	if len(parts) == 0 {
+		return fmt.Sprintf("No %s silences", state), nil
+	}
+
+	return strings.Join(parts, "\n\n"), commandError(errs)
 }
 
-// Silences returns a Markdown formatted NewMessage containing silences with the specified state.
-func (c *Client) Silences(state string) string {
-	silences, err := c.Alertmanager.Silence.List(context.TODO(), "")
+// silenceAdd parses the arguments of the `silence add` command and creates the silence.
+func (c *Client) silenceAdd(author, am string, args []string) (*bot.Message, error) {
+	parsed, err := parseArgs(args, []string{commentOption, atOption}, []string{confirmOption})
 	if err != nil {
-		return err.Error()
+		return errorMessage(err)
 	}
 
-	md := c.Formatter.FormatSilences(silences, state)
+	if len(parsed.args) <= 1 {
+		return insufficientArgs()
+	}
+
+	comment := silenceComment
+	if parsed.options[commentOption] != "" {
+		comment = parsed.options[commentOption]
+	}
+
+	startsAt := time.Now()
+
+	if at, ok := parsed.options[atOption]; ok {
+		if startsAt, err = parseTime(at); err != nil {
+			return errorMessage(err)
+		}
+	}
+
+	return c.NewSilence(author, am, parsed.args[0], strings.Join(parsed.args[1:], " "), comment, startsAt)
+}
+
+// NewSilence creates a new silence in the given Alertmanager,
+// and returns the ID and the firing alerts matched by the silence.
+// The silence starts at the given time, or immediately if that time has passed.
+// An error is returned if the silence would end before now.
+// An error is returned if the silence could not be created in one or more Alertmanagers.
+func (c *Client) NewSilence(author, am, durationStr, matchers, comment string,
+	startsAt time.Time) (*bot.Message, error) {
+	names, err := c.targetAlertmanagers(am)
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	duration, err := parseDuration(durationStr)
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	if endsAt := startsAt.Add(duration); endsAt.Before(time.Now()) {
+		return errorMessage(fmt.Errorf("%w: %s", errSilenceEnded, endsAt.Format("2006-01-02 15:04 MST")))
+	}
+
+	matches, messages := c.matchSilence(names, matchers)
+	alerts := matchedAlerts(matches)
+	errs := append([]string{}, messages...)
+
+	for _, m := range matches {
+		id, err := c.setSilence(c.Alertmanagers[m.name], author, comment, m.matchers, startsAt, duration)
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(m.name, err))
+			messages = append(messages, c.alertmanagerError(m.name, err))
+
+			continue
+		}
 
-	if md == "" {
-		return fmt.Sprintf("No %s silences", state)
+		message := c.silenceCreated(m.name, id)
+		if startsAt.After(time.Now()) {
+			message += fmt.Sprintf(", starting at %s", startsAt.Format("2006-01-02 15:04 MST"))
+		}
+
+		messages = append(messages, message)
 	}
 
-	return md
+	return c.matchesMessage(append(messages, matchCount(alerts)), alerts), commandError(errs)
 }
 
-// NewSilence creates a new silence and returns the ID.
-func (c *Client) NewSilence(author, durationStr string, matchers string) string {
+// newSilence creates a new silence for the given matchers or fingerprint, starting at the given time.
+func (c *Client) newSilence(am *alertmanager.Client, author, comment, durationStr, matchers string,
+	startsAt time.Time) (string, error) {
 	duration, err := parseDuration(durationStr)
 	if err != nil {
-		return err.Error()
+		return "", err
 	}
 
+	parsed, err := c.silenceMatchers(am, matchers)
+	if err != nil {
+		return "", err
+	}
+
+	return c.setSilence(am, author, comment, parsed, startsAt, duration)
+}
+
+// setSilence creates a new silence for the given matchers, starting at the given time.
+func (c *Client) setSilence(am *alertmanager.Client, author, comment string, matchers labels.Matchers,
+	startsAt time.Time, duration time.Duration) (string, error) {
 	silence := types.Silence{
-		Matchers:  make(labels.Matchers, len(matchers)),
-		StartsAt:  time.Now(),
-		EndsAt:    time.Now().Add(duration),
+		Matchers:  matchers,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(duration),
 		CreatedBy: author,
-		Comment:   "Created from Matrix",
+		Comment:   comment,
 	}
 
-	// Check if an ID is given instead of matchers
+	id, err := am.Silence.Set(context.Background(), silence)
+	if err != nil {
+		return "", fmt.Errorf("error creating silence: %w", err)
+	}
+
+	return id, nil
+}
+
+// silenceMatchers parses the given matchers, or returns the matchers for all labels of the alert
+// if an alert fingerprint is given instead.
+func (c *Client) silenceMatchers(am *alertmanager.Client, matchers string) (labels.Matchers, error) {
 	if !strings.ContainsAny(matchers, `{"=~!}`) {
-		res := c.addSilenceForFingerprint(&silence, matchers)
-		if res != "" {
-			return res
-		}
-	} else {
-		silence.Matchers, err = labels.ParseMatchers(matchers)
-		if err != nil {
-			return fmt.Sprintf("Invalid matchers: %s", err)
-		}
+		return c.fingerprintMatchers(am, matchers)
 	}
 
-	id, err := c.Alertmanager.Silence.Set(context.Background(), silence)
+	parsed, err := labels.ParseMatchers(matchers)
 	if err != nil {
-		return fmt.Sprintf("Error creating silence: %s", err)
+		return nil, fmt.Errorf("invalid matchers: %w", err)
 	}
 
-	return fmt.Sprintf("Silence created with ID *%s*", id)
+	return parsed, nil
 }
 
-func (c *Client) addSilenceForFingerprint(silence *types.Silence, fingerprint string) string {
-	alert, err := c.Alertmanager.GetAlert(fingerprint)
+// fingerprintMatchers returns matchers for all labels of the alert with the given fingerprint.
+func (c *Client) fingerprintMatchers(am *alertmanager.Client, fingerprint string) (labels.Matchers, error) {
+	alert, err := am.GetAlert(fingerprint)
 	if err != nil {
-		return err.Error()
+		return nil, err
 	}
 
 	if alert == nil {
-		return fmt.Sprintf("No alert with fingerprint %s", fingerprint)
+		return nil, fmt.Errorf("%w: %s", errNoAlert, fingerprint)
 	}
 
-	silence.Matchers = make(labels.Matchers, 0, len(alert.Labels))
+	matchers := make(labels.Matchers, 0, len(alert.Labels))
 	for name, value := range alert.Labels {
-		silence.Matchers = append(silence.Matchers, &labels.Matcher{
+		matchers = append(matchers, &labels.Matcher{
 			Name:  string(name),
 			Value: string(value),
 		})
 	}
 
-	return ""
+	return matchers, nil
 }
 
 // DelSilence deletes silences.
-func (c *Client) DelSilence(ids []string) string {
+// Each silence is deleted from the first selected Alertmanager that contains it.
+// An error is returned if one or more silences could not be deleted.
+func (c *Client) DelSilence(am string, ids []string) (string, error) {
 	if len(ids) == 0 {
-		return "No silence IDs provided"
+		return "No silence IDs provided", errInsufficientArgs
+	}
+
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return err.Error(), err
 	}
 
 	var errors []string
 
 	for _, id := range ids {
-		err := c.Alertmanager.Silence.Expire(context.TODO(), id)
-		if err != nil {
+		if _, err := c.expireSilence(names, id); err != nil {
 			errors = append(errors,
 				fmt.Sprintf("Error deleting %s: %s", id, err))
 		}
 	}
 
 	if errors != nil {
-		return strings.Join(errors, "\n\n")
+		return strings.Join(errors, "\n\n"), commandError(errors)
 	}
 
 	return fmt.Sprintf(
 		"Silences deleted: *%s*",
-		strings.Join(ids, ", "))
+		strings.Join(ids, ", ")), nil
+}
+
+// expireSilence expires a silence in the first of the given Alertmanagers that contains it,
+// and returns the name of that Alertmanager.
+func (c *Client) expireSilence(names []string, id string) (string, error) {
+	errs := make([]string, 0, len(names))
+
+	for _, name := range names {
+		err := c.Alertmanagers[name].Silence.Expire(context.TODO(), id)
+		if err == nil {
+			return name, nil
+		}
+
+		if len(names) == 1 {
+			return "", err
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+	}
+
+	return "", fmt.Errorf("%w: %s", errNoSilence, strings.Join(errs, "; "))
+}
+
+// alertmanagerError returns an error message that includes the name of the Alertmanager
+// if multiple Alertmanagers are configured.
+func (c *Client) alertmanagerError(name string, err error) string {
+	if c.multipleAlertmanagers() {
+		return fmt.Sprintf("%s: %s", name, err)
+	}
+
+	return err.Error()
+}
+
+// silenceCreated returns the message for a created silence.
+// The name of the Alertmanager is included if multiple Alertmanagers are configured.
+func (c *Client) silenceCreated(name, id string) string {
+	if c.multipleAlertmanagers() {
+		return fmt.Sprintf("Silence created in *%s* with ID *%s*", name, id)
+	}
+
+	return fmt.Sprintf("Silence created with ID *%s*", id)
 }
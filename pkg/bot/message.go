@@ -6,7 +6,9 @@ import (
 
 // Message represents the information for a single alert message.
 // It is used for formatting.
+// The fields of the webhook message are empty when formatting a list of alerts.
 type Message struct {
+	alertmanager.Message
 	Alerts     []*alertmanager.Alert
 	ShowLabels bool
 }
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Command outcomes.
+const (
+	outcomeSuccess = "success" // The command was executed and the response was sent.
+	outcomeFailure = "failure" // The command was executed, but the response could not be sent.
+	outcomeError   = "error"   // The command failed, and the error was sent as response.
+	outcomeDenied  = "denied"  // The command is not allowed in the room.
+	outcomeUnknown = "unknown" // The command does not exist.
+)
+
+// Prometheus metrics.
+var (
+	matrixSendDuration = promauto.NewHistogram(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "matrix",
+		Name:      "send_duration_seconds",
+		Help:      "Duration of sending messages to Matrix.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	matrixSendFailures = promauto.NewCounter(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "matrix",
+		Name:      "send_failures_total",
+		Help:      "Number of messages that could not be sent to Matrix.",
+	})
+
+	queueLength = promauto.NewGauge(prometheus.GaugeOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "queue",
+		Name:      "length",
+		Help:      "Number of notifications queued for retrying.",
+	})
+
+	queueDropped = promauto.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Subsystem: "queue",
+		Name:      "dropped_total",
+		Help:      "Number of queued notifications that were dropped by reason.",
+	}, []string{"reason"})
+
+	notificationsDeduplicated = promauto.NewCounter(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Name:      "notifications_deduplicated_total",
+		Help:      "Number of notifications that were ignored because they were already sent.",
+	})
+
+	commandsTotal = promauto.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Namespace: "alertmanager_matrix",
+		Name:      "commands_total",
+		Help:      "Number of bot commands by command and outcome.",
+	}, []string{"command", "outcome"})
+)
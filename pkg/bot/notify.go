@@ -0,0 +1,232 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// groupBucket is the store bucket containing the event IDs of alert group messages.
+const groupBucket = "groups"
+
+// resolvedStatus is the status of a resolved alert group.
+const resolvedStatus = "resolved"
+
+// NotifyMode determines how follow-up notifications for an alert group are sent.
+type NotifyMode string
+
+// Available notification modes.
+const (
+	NotifyMessage NotifyMode = "message" // Send every notification as a new message.
+	NotifyThread  NotifyMode = "thread"  // Send follow-up notifications in a thread.
+	NotifyEdit    NotifyMode = "edit"    // Edit the original message.
+)
+
+var errInvalidNotifyMode = errors.New("invalid notification mode")
+
+// ParseNotifyMode parses a notification mode.
+// The default mode is returned for an empty string.
+func ParseNotifyMode(s string) (NotifyMode, error) {
+	switch mode := NotifyMode(s); mode {
+	case "":
+		return NotifyMessage, nil
+	case NotifyMessage, NotifyThread, NotifyEdit:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errInvalidNotifyMode, s)
+	}
+}
+
+// Notify sends the alerts in an Alertmanager message to a room.
+// Follow-up notifications for the same alert group are sent according to the given mode,
+// or the mode configured for the room if the mode is empty.
+// The alert group is forgotten when it is resolved.
+//
+// Notifications that fail because of connection errors, rate limiting or server errors
+// are queued and retried in the background, replacing queued notifications for the same alert group.
+// Notifications that are identical to one sent to the room within the deduplication window are ignored.
+//
+// Notifications for different alert groups are sent concurrently,
+// notifications for the same alert group in a room are sent in order.
+func (c *Client) Notify(roomID string, message *alertmanager.Message, mode NotifyMode) error {
+	dedupKey := notificationKey(roomID, message)
+
+	duplicate, err := c.isDuplicate(dedupKey)
+	if err != nil {
+		return err
+	}
+
+	if duplicate {
+		log.Printf("Ignoring duplicate %s notification for %d alert(s) to %s",
+			message.Status, len(message.Alerts), roomID)
+		notificationsDeduplicated.Inc()
+
+		return nil
+	}
+
+	key := queueKey(roomID, message)
+
+	unlock := c.groupLocks.lock(key)
+	defer unlock()
+
+	if err = c.dequeue(key); err != nil {
+		return err
+	}
+
+	err = c.notify(roomID, message, mode)
+
+	switch {
+	case err == nil:
+		return nil
+	case !retryable(err):
+		// Allow Alertmanager to retry the notification
+		if forgetErr := c.forgetNotification(dedupKey); forgetErr != nil {
+			log.Printf("Error: %s", forgetErr)
+		}
+
+		return err
+	}
+
+	wait := backoff(1, err)
+	log.Printf("Error sending notification, retrying in %s: %s", wait, err)
+
+	return c.enqueue(key, &queueItem{
+		RoomID:   roomID,
+		Message:  message,
+		Mode:     mode,
+		Attempts: 1,
+		Created:  time.Now(),
+		Next:     time.Now().Add(wait),
+	})
+}
+
+// notify sends the alerts in an Alertmanager message to a room.
+// The lock of the alert group must be held.
+func (c *Client) notify(roomID string, message *alertmanager.Message, mode NotifyMode) error {
+	config := c.roomConfig(roomID)
+	if mode == "" {
+		mode = config.NotifyMode
+	}
+
+	// The Alertmanager that sent the message is unknown, so acknowledgements in all Alertmanagers of the room are used
+	names, err := c.alertmanagers(config.Alertmanager)
+	if err != nil {
+		return err
+	}
+
+	// Acknowledgements are best-effort, an unavailable Alertmanager should not delay notifications
+	ctx, cancel := context.WithTimeout(context.Background(), ackAnnotateTimeout)
+	defer cancel()
+
+	for _, name := range names {
+		if err = c.annotateAcks(ctx, c.Alertmanagers[name], message.Alerts); err != nil {
+			log.Printf("Error retrieving acknowledgements from %s: %s", name, err)
+		}
+	}
+
+	msg := bot.NewHTMLMessage(config.Formatter.FormatMessage(message, *config.ShowLabels))
+	msg.MsgType = config.MessageType
+	key := roomID + " " + message.GroupKey
+
+	// Alert groups that were first sent long ago are sent as a new message
+	if err = c.store.Prune(groupBucket, stateRetention); err != nil {
+		return fmt.Errorf("error pruning message state: %w", err)
+	}
+
+	eventID, followUp := c.store.Get(groupBucket, key)
+	followUp = followUp && message.GroupKey != "" && mode != NotifyMessage
+
+	var content interface{} = msg
+
+	switch {
+	case followUp && mode == NotifyThread:
+		content = newThreadMessage(eventID, msg)
+	case followUp:
+		content = newReplacement(eventID, msg)
+	}
+
+	sentID, err := c.sendMessage(roomID, content)
+	if err != nil {
+		return err
+	}
+
+	// Remember the alerts in the message that is shown to users
+	shownID := sentID
+	if followUp && mode == NotifyEdit {
+		shownID = eventID
+	}
+
+	if c.silenceReaction != "" {
+		if err = c.rememberAlerts(roomID, shownID, message.Alerts); err != nil {
+			return fmt.Errorf("error storing message state: %w", err)
+		}
+	}
+
+	if message.GroupKey == "" {
+		return nil
+	}
+
+	switch {
+	case message.Status == resolvedStatus:
+		err = c.store.Delete(groupBucket, key)
+	case !followUp && mode != NotifyMessage:
+		err = c.store.Set(groupBucket, key, sentID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error storing message state: %w", err)
+	}
+
+	return nil
+}
+
+// keyLock provides a mutex for each key.
+type keyLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyLockEntry
+}
+
+// keyLockEntry is the mutex for a single key, and the number of users holding or waiting for it.
+type keyLockEntry struct {
+	mu    sync.Mutex
+	users int
+}
+
+// lock locks the mutex for a key, and returns a function that unlocks it.
+// The mutex is removed when it is no longer in use.
+func (l *keyLock) lock(key string) func() {
+	l.mu.Lock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLockEntry)
+	}
+
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = new(keyLockEntry)
+		l.locks[key] = entry
+	}
+
+	entry.users++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if entry.users--; entry.users == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+func TestNotifyConcurrentRooms(t *testing.T) {
+	slowRoom, fastRoom := "!slow:example.com", "!fast:example.com"
+	fastSent := make(chan struct{})
+
+	var once sync.Once
+
+	client := newTestClient(t, testHandlers{
+		"/rooms/" + slowRoom + "/send/": func(w http.ResponseWriter, r *http.Request) {
+			// Block the slow room until the fast room has been sent to
+			select {
+			case <-fastSent:
+				_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+			case <-time.After(5 * time.Second):
+				http.Error(w, `{"errcode":"M_UNKNOWN"}`, http.StatusBadRequest)
+			}
+		},
+		"/rooms/" + fastRoom + "/send/": func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() { close(fastSent) })
+
+			_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+		},
+		"/api/v2/silences": respond(`[]`),
+	}, nil)
+	started := make(chan struct{})
+	errs := make(chan error, 1)
+
+	go func() {
+		close(started)
+		errs <- client.Notify(slowRoom, &alertmanager.Message{Status: "firing", GroupKey: "slow"}, "")
+	}()
+
+	<-started
+
+	if err := client.Notify(fastRoom, &alertmanager.Message{Status: "firing", GroupKey: "fast"}, ""); err != nil {
+		t.Fatalf("Error notifying %s: %s", fastRoom, err)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("Error notifying %s: %s", slowRoom, err)
+	}
+}
+
+func TestParseNotifyMode(t *testing.T) {
+	tests := map[string]struct {
+		mode NotifyMode
+		err  error
+	}{
+		"":        {mode: NotifyMessage},
+		"message": {mode: NotifyMessage},
+		"thread":  {mode: NotifyThread},
+		"edit":    {mode: NotifyEdit},
+		"replace": {err: errInvalidNotifyMode},
+	}
+
+	for str, test := range tests {
+		mode, err := ParseNotifyMode(str)
+		if mode != test.mode || !errors.Is(err, test.err) {
+			t.Errorf("Expected %q and error %v for %q, got %q and %v", test.mode, test.err, str, mode, err)
+		}
+	}
+}
+
+func TestNotifyEditExpired(t *testing.T) {
+	roomID := "!room:example.com"
+	sent := make(chan string, 1)
+
+	client := newTestClient(t, testHandlers{
+		"/rooms/" + roomID + "/send/": func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			sent <- string(body)
+
+			_, _ = w.Write([]byte(`{"event_id":"$new"}`))
+		},
+		"/api/v2/silences": respond(`[]`),
+	}, &ClientConfig{NotifyMode: NotifyEdit})
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		replace bool
+	}{
+		{name: "recent", age: time.Hour, replace: true},
+		{name: "expired", age: stateRetention + time.Hour},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			key := roomID + " " + test.name
+			if err := client.store.Set(groupBucket, key, "$old"); err != nil {
+				t.Fatalf("Error storing message state: %s", err)
+			}
+
+			client.store.data[groupBucket][key].Time = time.Now().Add(-test.age)
+
+			if err := client.Notify(roomID, &alertmanager.Message{Status: "firing", GroupKey: test.name}, ""); err != nil {
+				t.Fatalf("Error notifying: %s", err)
+			}
+
+			if replaced := strings.Contains(<-sent, `"m.replace"`); replaced != test.replace {
+				t.Errorf("Expected the message to be replaced: %v, got %v", test.replace, replaced)
+			}
+		})
+	}
+}
+
+func TestKeyLock(t *testing.T) {
+	var locks keyLock
+
+	unlockA := locks.lock("a")
+	unlockB := locks.lock("b") // Does not block
+
+	locked, done := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		unlock := locks.lock("a")
+		close(locked)
+		unlock()
+		close(done)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("Expected the lock for the same key to block")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unlockA()
+	<-done
+	unlockB()
+
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected unused locks to be removed, got %d", len(locks.locks))
+	}
+}
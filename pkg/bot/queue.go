@@ -0,0 +1,311 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/matrix-org/gomatrix"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// queueBucket is the store bucket containing notifications that could not be delivered.
+const queueBucket = "queue"
+
+// Retry queue settings.
+const (
+	defaultQueueSize   = 100              // Default maximum number of queued notifications.
+	defaultRetryMaxAge = "1h"             // Default time after which queued notifications are dropped.
+	retryMinBackoff    = time.Second      // Delay before the first retry.
+	retryMaxBackoff    = 5 * time.Minute  // Maximum delay between retries.
+	retryPollInterval  = 30 * time.Second // Maximum time between checks of the queue.
+)
+
+// Reasons for dropping queued notifications.
+const (
+	dropReasonFull    = "full"    // The queue is full.
+	dropReasonExpired = "expired" // The notification could not be delivered within the maximum age.
+	dropReasonFailed  = "failed"  // The notification failed with an error that cannot be retried.
+)
+
+// queueItem represents a notification that could not be delivered.
+type queueItem struct {
+	RoomID   string                `json:"room_id"`
+	Message  *alertmanager.Message `json:"message"`
+	Mode     NotifyMode            `json:"mode"`
+	Attempts int                   `json:"attempts"`
+	Created  time.Time             `json:"created"`
+	Next     time.Time             `json:"next"`
+}
+
+// queueKey returns the key of a queued notification.
+// Queued notifications for the same alert group in a room replace each other.
+func queueKey(roomID string, message *alertmanager.Message) string {
+	if message.GroupKey == "" {
+		return roomID + " " + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	return roomID + " " + message.GroupKey
+}
+
+// enqueue adds a notification to the retry queue.
+// The oldest notification is dropped if the queue is full.
+func (c *Client) enqueue(key string, item *queueItem) error {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	items := c.queuedItems()
+	if _, ok := items[key]; !ok && len(items) >= c.queueSize {
+		oldest := ""
+
+		for k, i := range items {
+			if oldest == "" || i.Created.Before(items[oldest].Created) {
+				oldest = k
+			}
+		}
+
+		if err := c.drop(oldest, items[oldest], dropReasonFull); err != nil {
+			return err
+		}
+	}
+
+	value, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("unable to encode notification: %w", err)
+	}
+
+	if err = c.store.Set(queueBucket, key, string(value)); err != nil {
+		return fmt.Errorf("error storing notification: %w", err)
+	}
+
+	queueLength.Set(float64(len(c.store.All(queueBucket))))
+
+	select {
+	case c.queueWake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// dequeue removes a notification from the retry queue.
+func (c *Client) dequeue(key string) error {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	return c.remove(key)
+}
+
+// remove removes a notification from the retry queue.
+// The queue lock must be held.
+func (c *Client) remove(key string) error {
+	if err := c.store.Delete(queueBucket, key); err != nil {
+		return fmt.Errorf("error removing notification: %w", err)
+	}
+
+	queueLength.Set(float64(len(c.store.All(queueBucket))))
+
+	return nil
+}
+
+// dropQueued removes a notification from the retry queue because it cannot be delivered.
+func (c *Client) dropQueued(key string, item *queueItem, reason string) error {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	return c.drop(key, item, reason)
+}
+
+// drop removes a notification from the retry queue because it cannot be delivered.
+// The queue lock must be held.
+func (c *Client) drop(key string, item *queueItem, reason string) error {
+	log.Printf("Dropping %s notification for %d alert(s) to %s after %d attempt(s): %s",
+		item.Message.Status, len(item.Message.Alerts), item.RoomID, item.Attempts, reason)
+
+	queueDropped.WithLabelValues(reason).Inc()
+
+	return c.remove(key)
+}
+
+// queuedItems returns all notifications in the retry queue.
+// Invalid notifications are ignored.
+// The queue lock must be held.
+func (c *Client) queuedItems() map[string]*queueItem {
+	values := c.store.All(queueBucket)
+	items := make(map[string]*queueItem, len(values))
+
+	for k, v := range values {
+		item := new(queueItem)
+		if err := json.Unmarshal([]byte(v), item); err != nil {
+			log.Printf("Ignoring invalid queued notification %q: %s", k, err)
+
+			continue
+		}
+
+		items[k] = item
+	}
+
+	return items
+}
+
+// processQueue retries queued notifications until the client is stopped.
+func (c *Client) processQueue() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-c.queueWake:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+
+		timer.Reset(c.retryQueued())
+	}
+}
+
+// retryQueued retries all queued notifications that are due,
+// and returns the time until the next notification is due.
+func (c *Client) retryQueued() time.Duration {
+	c.queueMu.Lock()
+	items := c.queuedItems()
+	c.queueMu.Unlock()
+
+	next := retryPollInterval
+
+	for key, item := range items {
+		if wait := time.Until(item.Next); wait > 0 {
+			if wait < next {
+				next = wait
+			}
+
+			continue
+		}
+
+		if wait, ok := c.retry(key, item); ok && wait < next {
+			next = wait
+		}
+	}
+
+	return next
+}
+
+// retry attempts to deliver a queued notification.
+// The time until the next attempt is returned if the notification is still queued.
+// Nothing is sent if the notification has been sent or replaced in the meantime.
+func (c *Client) retry(key string, item *queueItem) (time.Duration, bool) {
+	unlock := c.groupLocks.lock(key)
+	defer unlock()
+
+	if !c.isQueued(key, item) {
+		return 0, false
+	}
+
+	item.Attempts++
+
+	err := c.notify(item.RoomID, item.Message, item.Mode)
+
+	switch {
+	case err == nil:
+		log.Printf("Sent queued %s notification for %d alert(s) to %s after %d attempt(s)",
+			item.Message.Status, len(item.Message.Alerts), item.RoomID, item.Attempts)
+
+		err = c.dequeue(key)
+	case !retryable(err):
+		log.Printf("Error sending queued notification: %s", err)
+
+		err = c.dropQueued(key, item, dropReasonFailed)
+	case time.Since(item.Created) > c.retryMaxAge:
+		log.Printf("Error sending queued notification: %s", err)
+
+		err = c.dropQueued(key, item, dropReasonExpired)
+	default:
+		wait := backoff(item.Attempts, err)
+		item.Next = time.Now().Add(wait)
+
+		log.Printf("Error sending queued notification, retrying in %s: %s", wait, err)
+
+		if err = c.enqueue(key, item); err == nil {
+			return wait, true
+		}
+	}
+
+	if err != nil {
+		log.Printf("Error updating notification queue: %s", err)
+	}
+
+	return 0, false
+}
+
+// isQueued returns true if the given notification is still in the retry queue.
+func (c *Client) isQueued(key string, item *queueItem) bool {
+	value, ok := c.store.Get(queueBucket, key)
+	if !ok {
+		return false
+	}
+
+	queued := new(queueItem)
+	if err := json.Unmarshal([]byte(value), queued); err != nil {
+		return false
+	}
+
+	return queued.Created.Equal(item.Created) && queued.Attempts == item.Attempts
+}
+
+// retryable returns true if a notification that failed with the given error can be retried.
+// This is the case for connection errors, rate limiting and server errors.
+func retryable(err error) bool {
+	var httpErr gomatrix.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code == http.StatusTooManyRequests || httpErr.Code >= http.StatusInternalServerError
+	}
+
+	var urlErr *url.Error
+
+	return errors.As(err, &urlErr)
+}
+
+// backoff returns the delay before the next attempt to deliver a notification.
+// The delay doubles for every attempt, unless the homeserver requests a longer delay.
+func backoff(attempts int, err error) time.Duration {
+	wait := retryMinBackoff
+	for i := 1; i < attempts && wait < retryMaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > retryMaxBackoff {
+		wait = retryMaxBackoff
+	}
+
+	if after := retryAfter(err); after > wait {
+		wait = after
+	}
+
+	return wait
+}
+
+// retryAfter returns the delay requested by the homeserver in a rate limiting error, if any.
+func retryAfter(err error) time.Duration {
+	var httpErr gomatrix.HTTPError
+	if !errors.As(err, &httpErr) {
+		return 0
+	}
+
+	var resp struct {
+		RetryAfterMS int64 `json:"retry_after_ms"`
+	}
+
+	if json.Unmarshal(httpErr.Contents, &resp) != nil {
+		return 0
+	}
+
+	return time.Duration(resp.RetryAfterMS) * time.Millisecond
+}
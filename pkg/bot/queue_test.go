@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/matrix-org/gomatrix"
+)
+
+func TestBackoff(t *testing.T) {
+	rateLimited := func(contents string) error {
+		return fmt.Errorf("error sending message: %w",
+			gomatrix.HTTPError{Code: 429, Message: "Too Many Requests", Contents: []byte(contents)})
+	}
+
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		expected time.Duration
+	}{
+		{name: "first attempt", attempts: 1, err: errors.New("test"), expected: time.Second},
+		{name: "second attempt", attempts: 2, err: errors.New("test"), expected: 2 * time.Second},
+		{name: "doubles", attempts: 5, err: errors.New("test"), expected: 16 * time.Second},
+		{name: "cap", attempts: 10, err: errors.New("test"), expected: retryMaxBackoff},
+		{name: "many attempts", attempts: 1000, err: errors.New("test"), expected: retryMaxBackoff},
+		{name: "retry after", attempts: 1, err: rateLimited(`{"retry_after_ms":2500}`), expected: 2500 * time.Millisecond},
+		{name: "shorter retry after", attempts: 5, err: rateLimited(`{"retry_after_ms":100}`), expected: 16 * time.Second},
+		{name: "retry after above cap", attempts: 10, err: rateLimited(`{"retry_after_ms":600000}`), expected: 10 * time.Minute},
+		{name: "no retry after", attempts: 2, err: rateLimited(`{"errcode":"M_LIMIT_EXCEEDED"}`), expected: 2 * time.Second},
+		{name: "invalid contents", attempts: 2, err: rateLimited(`<html>`), expected: 2 * time.Second},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if wait := backoff(test.attempts, test.err); wait != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, wait)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "rate limited", err: gomatrix.HTTPError{Code: 429}, expected: true},
+		{name: "server error", err: fmt.Errorf("test: %w", gomatrix.HTTPError{Code: 502}), expected: true},
+		{name: "forbidden", err: gomatrix.HTTPError{Code: 403}},
+		{name: "connection", err: &url.Error{Op: "Put", URL: "http://localhost", Err: errors.New("refused")}, expected: true},
+		{name: "other", err: errors.New("test")},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if retryable(test.err) != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, !test.expected)
+			}
+		})
+	}
+}
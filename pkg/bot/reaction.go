@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Matrix event types used for reactions.
+const (
+	eventTypeReaction  bot.EventType = "m.reaction"
+	eventTypeRedaction bot.EventType = "m.room.redaction"
+)
+
+// Store buckets for reactions.
+const (
+	alertBucket    = "alerts"    // Fingerprints of the alerts in a message, by room and event ID.
+	reactionBucket = "reactions" // Sender and silences created by a reaction, by room and event ID.
+)
+
+// Commands that reactions are equivalent to, for checking whether they are allowed in a room.
+const (
+	silenceAddCommand = "silence add"
+	silenceDelCommand = "silence del"
+)
+
+// relationAnnotation is the relation type of reactions.
+const relationAnnotation = "m.annotation"
+
+// stateRetention is the duration for which alerts, reactions and alert group messages are remembered.
+const stateRetention = Week
+
+// rememberAlerts stores the fingerprints of the firing alerts rendered in an event in a room.
+func (c *Client) rememberAlerts(roomID, eventID string, alerts []*alertmanager.Alert) error {
+	key := roomID + " " + eventID
+
+	fingerprints := make([]string, 0, len(alerts))
+
+	for _, a := range alerts {
+		if a.Fingerprint != "" && a.Status != resolvedStatus {
+			fingerprints = append(fingerprints, a.Fingerprint)
+		}
+	}
+
+	if len(fingerprints) == 0 {
+		return c.store.Delete(alertBucket, key)
+	}
+
+	if err := c.store.Prune(alertBucket, stateRetention); err != nil {
+		return err
+	}
+
+	return c.store.Set(alertBucket, key, strings.Join(fingerprints, ","))
+}
+
+// handleReaction creates silences for the alerts in a message that is reacted to
+// with the silence reaction, or executes the command confirmed by the confirmation reaction.
+func (c *Client) handleReaction(e *bot.Event) {
+	room := c.Matrix.NewRoom(e.RoomID)
+	if e.Sender == c.Matrix.Client.UserID || !c.roomAllowed(room.ID) {
+		return
+	}
+
+	relatesTo, _ := e.Content["m.relates_to"].(map[string]interface{})
+	relType, _ := relatesTo["rel_type"].(string)
+	eventID, _ := relatesTo["event_id"].(string)
+	key, _ := relatesTo["key"].(string)
+
+	if relType != relationAnnotation {
+		return
+	}
+
+	if trimVariation(key) == trimVariation(confirmReaction) && len(c.confirmCommands) > 0 {
+		c.handleConfirmReaction(room.ID, e.Sender, eventID)
+		return
+	}
+
+	if c.silenceReaction == "" || trimVariation(key) != trimVariation(c.silenceReaction) {
+		return
+	}
+
+	// Only messages in the same room can be reacted to
+	fingerprints, ok := c.store.Get(alertBucket, room.ID+" "+eventID)
+	if !ok {
+		return
+	}
+
+	config := c.roomConfig(room.ID)
+	if !config.CommandAllowed(silenceAddCommand) {
+		c.sendResponse(room.ID, fmt.Sprintf("command not allowed in this room: %q", silenceAddCommand))
+		return
+	}
+
+	names, err := c.alertmanagers(config.Alertmanager)
+	if err != nil {
+		c.sendResponse(room.ID, err.Error())
+		return
+	}
+
+	var ids, messages []string
+
+	for _, fingerprint := range strings.Split(fingerprints, ",") {
+		refs, errs := c.silenceFingerprint(names, e.Sender, fingerprint)
+		for _, err := range errs {
+			messages = append(messages, fmt.Sprintf("Error silencing %s: %s", fingerprint, err))
+		}
+
+		for _, ref := range refs {
+			name, id := splitSilenceRef(ref)
+			ids = append(ids, ref)
+			messages = append(messages, c.silenceCreated(name, id))
+		}
+	}
+
+	if len(ids) > 0 {
+		err := c.store.Set(reactionBucket, room.ID+" "+e.ID, e.Sender+" "+strings.Join(ids, ","))
+		if err == nil {
+			err = c.store.Prune(reactionBucket, stateRetention)
+		}
+
+		if err != nil {
+			log.Printf("Error storing reaction state: %s", err)
+		}
+	}
+
+	c.sendResponse(room.ID, strings.Join(messages, "\n\n"))
+}
+
+// handleRedaction expires the silences created by a reaction when the reaction is removed by its sender.
+func (c *Client) handleRedaction(e *bot.Event) {
+	room := c.Matrix.NewRoom(e.RoomID)
+	if e.Sender == c.Matrix.Client.UserID || !c.roomAllowed(room.ID) {
+		return
+	}
+
+	redacts := e.Redacts
+	if redacts == "" {
+		redacts, _ = e.Content["redacts"].(string)
+	}
+
+	key := room.ID + " " + redacts
+
+	reaction, ok := c.store.Get(reactionBucket, key)
+	if !ok {
+		return
+	}
+
+	// Only the sender of the reaction can expire the silences
+	parts := strings.SplitN(reaction, " ", 2)
+	if len(parts) != 2 || parts[0] != e.Sender {
+		return
+	}
+
+	ids := parts[1]
+
+	if !c.roomConfig(room.ID).CommandAllowed(silenceDelCommand) {
+		c.sendResponse(room.ID, fmt.Sprintf("command not allowed in this room: %q", silenceDelCommand))
+		return
+	}
+
+	if err := c.store.Delete(reactionBucket, key); err != nil {
+		log.Printf("Error storing reaction state: %s", err)
+	}
+
+	var expired, messages []string
+
+	for _, ref := range strings.Split(ids, ",") {
+		name, id := splitSilenceRef(ref)
+		if _, ok := c.Alertmanagers[name]; !ok {
+			messages = append(messages, fmt.Sprintf("Error deleting %s: %s: %q", id, errUnknownAlertmanager, name))
+			continue
+		}
+
+		if _, err := c.expireSilence([]string{name}, id); err != nil {
+			messages = append(messages, fmt.Sprintf("Error deleting %s: %s", id, err))
+			continue
+		}
+
+		expired = append(expired, id)
+	}
+
+	if len(expired) > 0 {
+		messages = append(messages, fmt.Sprintf("Silences deleted: *%s*", strings.Join(expired, ", ")))
+	}
+
+	c.sendResponse(room.ID, strings.Join(messages, "\n\n"))
+}
+
+// silenceFingerprint creates silences for the alert with the given fingerprint
+// in each of the given Alertmanagers that contains it.
+// It returns references to the created silences in the form `<alertmanager>/<id>`.
+func (c *Client) silenceFingerprint(names []string, author, fingerprint string) (refs []string, errs []string) {
+	for _, name := range names {
+		id, err := c.newSilence(c.Alertmanagers[name], author, silenceComment, c.silenceDuration, fingerprint, time.Now())
+		if errors.Is(err, errNoAlert) && len(names) > 1 {
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			continue
+		}
+
+		refs = append(refs, name+"/"+id)
+	}
+
+	if len(refs) == 0 && len(errs) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: %s", errNoAlert, fingerprint))
+	}
+
+	return refs, errs
+}
+
+// splitSilenceRef splits a silence reference into the name of the Alertmanager and the silence ID.
+func splitSilenceRef(ref string) (string, string) {
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+
+	return "", ref
+}
+
+// sendResponse sends a Markdown formatted response to a room.
+func (c *Client) sendResponse(roomID string, markdown string) {
+	msg := bot.NewMarkdownMessage(markdown)
+	msg.MsgType = c.roomConfig(roomID).MessageType
+
+	if _, err := c.sendMessage(roomID, msg); err != nil {
+		log.Printf("Error sending message: %s", err)
+	}
+}
+
+// trimVariation removes emoji variation selectors from a string.
+func trimVariation(s string) string {
+	return strings.ReplaceAll(s, "\ufe0f", "")
+}
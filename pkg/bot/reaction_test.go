@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/matrix-org/gomatrix"
+	bot "gitlab.com/silkeh/matrix-bot"
+)
+
+func TestReactionSilence(t *testing.T) {
+	roomID, otherRoomID := "!room:example.com", "!other:example.com"
+	created, expired := 0, 0
+
+	handlers := silenceHandlers(&created, `[{"labels":{"job":"a"},"fingerprint":"0001","status":{"state":"active"}}]`)
+	handlers["/api/v2/silence/"] = func(w http.ResponseWriter, r *http.Request) { expired++ }
+	handlers["/send/"] = respond(`{"event_id":"$response"}`)
+
+	client := newTestClient(t, handlers, &ClientConfig{SilenceReaction: "🔕", SilenceDuration: "1h"})
+
+	message := decodeMessage(t, `{"status":"firing","alerts":[{"fingerprint":"0001","status":"firing"}]}`)
+	if err := client.rememberAlerts(roomID, "$alert", message.Alerts); err != nil {
+		t.Fatalf("Error storing alerts: %s", err)
+	}
+
+	reaction := func(roomID, sender string) *bot.Event {
+		return &bot.Event{Event: &gomatrix.Event{
+			ID:     "$reaction",
+			RoomID: roomID,
+			Sender: sender,
+			Type:   string(eventTypeReaction),
+			Content: map[string]interface{}{"m.relates_to": map[string]interface{}{
+				"rel_type": relationAnnotation,
+				"event_id": "$alert",
+				"key":      "🔕",
+			}},
+		}}
+	}
+	redaction := func(roomID, sender string) *bot.Event {
+		return &bot.Event{Event: &gomatrix.Event{
+			ID:      "$redaction",
+			RoomID:  roomID,
+			Sender:  sender,
+			Type:    string(eventTypeRedaction),
+			Redacts: "$reaction",
+		}}
+	}
+
+	// The steps are executed in order, with the total number of created and expired silences after each step
+	tests := []struct {
+		name    string
+		event   *bot.Event
+		handler func(*bot.Event)
+		created int
+		expired int
+	}{
+		{name: "reaction in other room", event: reaction(otherRoomID, "@user:example.com"), handler: client.handleReaction},
+		{name: "reaction", event: reaction(roomID, "@user:example.com"), handler: client.handleReaction, created: 1},
+		{
+			name:    "redaction in other room",
+			event:   redaction(otherRoomID, "@user:example.com"),
+			handler: client.handleRedaction,
+			created: 1,
+		},
+		{
+			name:    "redaction by other user",
+			event:   redaction(roomID, "@other:example.com"),
+			handler: client.handleRedaction,
+			created: 1,
+		},
+		{
+			name:    "redaction",
+			event:   redaction(roomID, "@user:example.com"),
+			handler: client.handleRedaction,
+			created: 1,
+			expired: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test.handler(test.event)
+
+		if created != test.created || expired != test.expired {
+			t.Errorf("Expected %d created and %d expired silences after %s, got %d and %d",
+				test.created, test.expired, test.name, created, expired)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoomConfig contains the configuration for a single room.
+// Unset values default to the configuration of the client.
+type RoomConfig struct {
+	Formatter   *Formatter // Formatter for alert messages (optional).
+	MessageType string     // Matrix message type (optional).
+	ShowLabels  *bool      // Show labels in alert messages (optional).
+	NotifyMode  NotifyMode // Notification mode for alert groups (optional).
+	Commands    []string   // Allowed commands (optional). All commands are allowed if empty.
+
+	// Alertmanager contains the name of the Alertmanager used by commands in the room (optional).
+	Alertmanager string
+
+	// WebhookAuth contains the credentials required for webhooks to the room (optional).
+	// No credentials are required if none are configured for the room or the client.
+	WebhookAuth *WebhookAuth
+}
+
+// RoomConfig returns the configuration for a room ID with all defaults filled in.
+func (c *Client) RoomConfig(roomID string) *RoomConfig {
+	return c.roomConfig(roomID)
+}
+
+// RoomWebhookAuth returns the credentials configured for a room itself,
+// or nil if the room uses the global credentials.
+// Webhooks that send alerts to the room must provide these credentials, regardless of the endpoint.
+func (c *Client) RoomWebhookAuth(roomID string) *WebhookAuth {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	if room, ok := c.rooms[roomID]; ok && room.WebhookAuth.Enabled() {
+		return room.WebhookAuth
+	}
+
+	return nil
+}
+
+// roomConfig returns the configuration for a room with all defaults filled in.
+func (c *Client) roomConfig(roomID string) *RoomConfig {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	showLabels := c.showLabels
+	config := &RoomConfig{
+		Formatter:    c.Formatter,
+		MessageType:  c.Matrix.Config.MessageType,
+		ShowLabels:   &showLabels,
+		NotifyMode:   c.notifyMode,
+		WebhookAuth:  c.webhookAuth,
+		Alertmanager: c.defaultAlertmanager,
+	}
+
+	room, ok := c.rooms[roomID]
+	if !ok {
+		return config
+	}
+
+	if room.Formatter != nil {
+		config.Formatter = room.Formatter
+	}
+
+	if room.MessageType != "" {
+		config.MessageType = room.MessageType
+	}
+
+	if room.ShowLabels != nil {
+		config.ShowLabels = room.ShowLabels
+	}
+
+	if room.NotifyMode != "" {
+		config.NotifyMode = room.NotifyMode
+	}
+
+	if room.WebhookAuth.Enabled() {
+		config.WebhookAuth = room.WebhookAuth
+	}
+
+	if room.Alertmanager != "" {
+		config.Alertmanager = room.Alertmanager
+	}
+
+	config.Commands = room.Commands
+
+	return config
+}
+
+// formatter returns the default formatter of the client.
+func (c *Client) formatter() *Formatter {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	return c.Formatter
+}
+
+// validateRoomConfigs returns a copy of the given room configuration after validating it.
+func (c *Client) validateRoomConfigs(configs map[string]*RoomConfig) (map[string]*RoomConfig, error) {
+	rooms := make(map[string]*RoomConfig, len(configs))
+
+	for id, room := range configs {
+		if room.NotifyMode != "" {
+			if _, err := ParseNotifyMode(string(room.NotifyMode)); err != nil {
+				return nil, fmt.Errorf("invalid configuration for room %q: %w", id, err)
+			}
+		}
+
+		if err := c.validateAlertmanager(room.Alertmanager); err != nil {
+			return nil, fmt.Errorf("invalid configuration for room %q: %w", id, err)
+		}
+
+		rooms[id] = room
+	}
+
+	return rooms, nil
+}
+
+// resolveRoomConfigs replaces the room aliases in the room configuration by room IDs.
+// Rooms are not joined.
+func (c *Client) resolveRoomConfigs() error {
+	rooms := make(map[string]*RoomConfig, len(c.rooms))
+
+	for r, room := range c.rooms {
+		id, err := c.ResolveRoom(r)
+		if err != nil {
+			return fmt.Errorf("invalid configuration for room %q: %w", r, err)
+		}
+
+		rooms[id] = room
+	}
+
+	c.rooms = rooms
+
+	return nil
+}
+
+// Reload replaces the formatter, the webhook credentials and the configuration of alert messages, rooms and routes.
+// Other configuration is not changed.
+// Rooms that were not configured before are joined,
+// and added to the list of allowed rooms if commands are limited to a list of rooms.
+func (c *Client) Reload(config *ClientConfig, formatter *Formatter) error {
+	if config == nil {
+		return errNilClientConfig
+	}
+
+	if formatter == nil {
+		formatter = NewFormatter("", "", nil, nil)
+	}
+
+	mode, err := ParseNotifyMode(string(config.NotifyMode))
+	if err != nil {
+		return err
+	}
+
+	configs, err := c.validateRoomConfigs(config.RoomConfigs)
+	if err != nil {
+		return err
+	}
+
+	routes, err := validateRoutes(config.Routes)
+	if err != nil {
+		return err
+	}
+
+	rooms := make(map[string]*RoomConfig, len(configs))
+	ids := make([]string, 0, len(configs))
+
+	for r, room := range configs {
+		id, err := c.resolveRoom(r)
+		if err != nil {
+			return err
+		}
+
+		rooms[id] = room
+		ids = append(ids, id)
+	}
+
+	for _, route := range routes {
+		for _, r := range route.Rooms {
+			id, err := c.resolveRoom(r)
+			if err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+	}
+
+	joined, err := c.joinNewRooms(ids)
+	if err != nil {
+		return err
+	}
+
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+
+	c.joinList = append(c.joinList, joined...)
+
+	// The list is replaced to avoid modifying it while it is in use
+	if allowed := c.Matrix.Config.AllowedRooms; len(allowed) > 0 {
+		c.Matrix.Config.AllowedRooms = append(append([]string{}, allowed...), joined...)
+	}
+
+	c.Formatter = formatter
+	c.showLabels = config.ShowLabels
+	c.notifyMode = mode
+	c.webhookAuth = config.WebhookAuth
+	c.rooms = rooms
+	c.routes = routes
+
+	return nil
+}
+
+// resolveRoom returns the room ID for a room ID or alias.
+// Unknown aliases are resolved by joining the room.
+func (c *Client) resolveRoom(r string) (string, error) {
+	if strings.HasPrefix(r, "!") {
+		return r, nil
+	}
+
+	if id, ok := c.cachedAlias(r); ok && id != "" {
+		return id, nil
+	}
+
+	id, err := c.Matrix.NewRoom(r).Join()
+	if err != nil {
+		return "", fmt.Errorf("cannot join room %q: %w", r, err)
+	}
+
+	c.cacheAlias(r, id)
+
+	return id, nil
+}
+
+// joinNewRooms joins the rooms that have not been joined before, and returns their IDs.
+func (c *Client) joinNewRooms(ids []string) ([]string, error) {
+	c.configMu.RLock()
+	known := append([]string{}, c.joinList...)
+	c.configMu.RUnlock()
+
+	var joined []string
+
+	for _, id := range ids {
+		if contains(known, id) || contains(joined, id) {
+			continue
+		}
+
+		if _, err := c.Matrix.NewRoom(id).Join(); err != nil {
+			return nil, fmt.Errorf("cannot join room %q: %w", id, err)
+		}
+
+		joined = append(joined, id)
+	}
+
+	return joined, nil
+}
+
+// ConfiguredRoom returns true if the room with the given ID is joined because it is configured
+// as an allowed room, in the room configuration or in a route.
+// Aliases are only recognized after the client has joined them.
+func (c *Client) ConfiguredRoom(roomID string) bool {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	if _, ok := c.rooms[roomID]; ok {
+		return true
+	}
+
+	return contains(c.joinList, roomID) || contains(c.Matrix.Config.AllowedRooms, roomID)
+}
+
+// roomAllowed returns true if commands are allowed in the room with the given ID.
+func (c *Client) roomAllowed(roomID string) bool {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	return c.Matrix.NewRoom(roomID).Allowed()
+}
+
+// CommandAllowed returns true if the given command path (eg: `silence add`) is allowed in the room.
+// A command is allowed if it, or any of its parent commands, is listed in the allowed commands.
+// The `help` command is always allowed, and the bare command is allowed if `list` is allowed.
+func (r *RoomConfig) CommandAllowed(path string) bool {
+	if len(r.Commands) == 0 || path == "help" || strings.HasPrefix(path, "help ") {
+		return true
+	}
+
+	if path == "" {
+		path = "list"
+	}
+
+	for _, allowed := range r.Commands {
+		if path == allowed || strings.HasPrefix(path, allowed+" ") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,27 @@
+package bot
+
+import (
+	"testing"
+)
+
+func TestConfiguredRoom(t *testing.T) {
+	client := newTestClient(t, nil, &ClientConfig{
+		Rooms:       "!allowed:example.com",
+		RoomConfigs: map[string]*RoomConfig{"!room:example.com": {}},
+		Routes:      []*Route{{Rooms: []string{"!route:example.com"}}},
+	})
+
+	tests := map[string]bool{
+		"!allowed:example.com": true,
+		"!room:example.com":    true,
+		"!route:example.com":   true,
+		"!other:example.com":   false,
+		"":                     false,
+	}
+
+	for roomID, expected := range tests {
+		if client.ConfiguredRoom(roomID) != expected {
+			t.Errorf("Expected %q to be configured: %v, got %v", roomID, expected, !expected)
+		}
+	}
+}
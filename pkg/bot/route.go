@@ -0,0 +1,302 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matrix-org/gomatrix"
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// Times room aliases resolved using the room directory are cached.
+const (
+	aliasCacheTTL        = 5 * time.Minute // Time aliases of existing rooms are cached.
+	unknownAliasCacheTTL = time.Minute     // Time aliases that do not exist are cached.
+)
+
+var (
+	// ErrInvalidRoom is returned for values that are neither a room ID nor a room alias.
+	ErrInvalidRoom = errors.New("invalid room ID or alias")
+
+	// ErrUnknownRoom is returned for room aliases that do not exist.
+	ErrUnknownRoom = errors.New("unknown room alias")
+
+	errInvalidRoute   = errors.New("invalid route")
+	errDuplicateRoute = errors.New("duplicate route")
+)
+
+// Route contains a set of rooms that receive the same alerts.
+// Alerts are sent to a route when a webhook is sent to its name,
+// or when the route is the first route that matches the alert.
+type Route struct {
+	Name     string   // Name of the route (optional).
+	Rooms    []string // Room IDs or aliases.
+	Matchers []string // Label matchers for alerts (optional). All alerts are matched if empty.
+	Continue bool     // Continue matching subsequent routes after matching this route.
+
+	// WebhookAuth contains the credentials required for webhooks to the route (optional).
+	// Defaults to the credentials configured for the client.
+	WebhookAuth *WebhookAuth
+}
+
+// route is a route with parsed matchers.
+type route struct {
+	*Route
+	matchers labels.Matchers
+}
+
+// alias is a cached room alias.
+type alias struct {
+	ID   string
+	Time time.Time
+}
+
+// validateRoutes returns the given routes with parsed matchers after validating them.
+func validateRoutes(routes []*Route) ([]*route, error) {
+	parsed := make([]*route, 0, len(routes))
+	names := make(map[string]bool, len(routes))
+
+	for i, r := range routes {
+		if r == nil || len(r.Rooms) == 0 {
+			return nil, fmt.Errorf("%w: route %d requires at least one room", errInvalidRoute, i)
+		}
+
+		if names[r.Name] {
+			return nil, fmt.Errorf("%w: %q", errDuplicateRoute, r.Name)
+		}
+
+		if r.Name != "" {
+			names[r.Name] = true
+		}
+
+		p := &route{Route: r}
+
+		for _, m := range r.Matchers {
+			matchers, err := labels.ParseMatchers(m)
+			if err != nil {
+				return nil, fmt.Errorf("%w: route %d has invalid matchers: %s", errInvalidRoute, i, err)
+			}
+
+			p.matchers = append(p.matchers, matchers...)
+		}
+
+		parsed = append(parsed, p)
+	}
+
+	return parsed, nil
+}
+
+// RouteWebhookAuth returns the credentials required for webhooks to the route with the given name,
+// without resolving the rooms of the route.
+// False is returned if the route does not exist.
+func (c *Client) RouteWebhookAuth(name string) (*WebhookAuth, bool) {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	for _, r := range c.routes {
+		if name == "" || r.Name != name {
+			continue
+		}
+
+		if r.WebhookAuth.Enabled() {
+			return r.WebhookAuth, true
+		}
+
+		return c.webhookAuth, true
+	}
+
+	return nil, false
+}
+
+// Route returns the route with the given name with the rooms resolved to room IDs
+// and the default credentials filled in.
+// False is returned if the route does not exist.
+func (c *Client) Route(name string) (*Route, bool, error) {
+	if name == "" {
+		return nil, false, nil
+	}
+
+	var route *Route
+
+	c.configMu.RLock()
+	for _, r := range c.routes {
+		if r.Name == name {
+			route = r.Route
+
+			break
+		}
+	}
+	auth := c.webhookAuth
+	c.configMu.RUnlock()
+
+	if route == nil {
+		return nil, false, nil
+	}
+
+	resolved := &Route{
+		Name:        route.Name,
+		Rooms:       make([]string, len(route.Rooms)),
+		WebhookAuth: route.WebhookAuth,
+	}
+
+	if !resolved.WebhookAuth.Enabled() {
+		resolved.WebhookAuth = auth
+	}
+
+	for i, r := range route.Rooms {
+		id, err := c.ResolveRoom(r)
+		if err != nil {
+			return nil, true, err
+		}
+
+		resolved.Rooms[i] = id
+	}
+
+	return resolved, true, nil
+}
+
+// RouteAlerts returns the alerts in a message by room ID, based on the matchers of the routes.
+// Every alert is sent to the rooms of the first matching route,
+// and to those of subsequent matching routes if the route has `Continue` set.
+// Alerts that do not match any route are not returned.
+func (c *Client) RouteAlerts(message *alertmanager.Message) (map[string]*alertmanager.Message, error) {
+	c.configMu.RLock()
+	routes := c.routes
+	c.configMu.RUnlock()
+
+	messages := make(map[string]*alertmanager.Message)
+
+	for _, alert := range message.Alerts {
+		for _, r := range routes {
+			if !alert.MatchedBy(r.matchers) {
+				continue
+			}
+
+			for _, room := range r.Rooms {
+				id, err := c.ResolveRoom(room)
+				if err != nil {
+					return nil, err
+				}
+
+				addAlert(messages, id, message, alert)
+			}
+
+			if !r.Continue {
+				break
+			}
+		}
+	}
+
+	return messages, nil
+}
+
+// addAlert adds an alert to the message for a room.
+// The message is created from the original message if it does not exist.
+func addAlert(messages map[string]*alertmanager.Message, roomID string,
+	original *alertmanager.Message, alert *alertmanager.Alert,
+) {
+	msg, ok := messages[roomID]
+	if !ok {
+		msg = new(alertmanager.Message)
+		*msg = *original
+		msg.Alerts = nil
+		msg.Status = resolvedStatus
+		messages[roomID] = msg
+	}
+
+	for _, a := range msg.Alerts {
+		if a == alert {
+			return
+		}
+	}
+
+	msg.Alerts = append(msg.Alerts, alert)
+
+	if alert.Status != resolvedStatus {
+		msg.Status = original.Status
+	}
+}
+
+// ResolveRoom returns the room ID for a room ID or alias.
+// Aliases are resolved using the room directory, and cached for a short time.
+// Aliases that do not exist are cached as well.
+// Rooms are not joined.
+func (c *Client) ResolveRoom(r string) (string, error) {
+	switch {
+	case strings.HasPrefix(r, "!"):
+		return r, nil
+	case !strings.HasPrefix(r, "#"):
+		return "", fmt.Errorf("%w: %q", ErrInvalidRoom, r)
+	}
+
+	if id, ok := c.cachedAlias(r); ok {
+		if id == "" {
+			return "", fmt.Errorf("%w: %q", ErrUnknownRoom, r)
+		}
+
+		return id, nil
+	}
+
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+
+	err := c.Matrix.Client.MakeRequest(http.MethodGet, c.Matrix.Client.BuildURL("directory", "room", r), nil, &resp)
+	if err != nil {
+		var httpErr gomatrix.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound {
+			c.cacheAlias(r, "")
+
+			return "", fmt.Errorf("%w: %q", ErrUnknownRoom, r)
+		}
+
+		return "", fmt.Errorf("cannot resolve room alias %q: %w", r, err)
+	}
+
+	c.cacheAlias(r, resp.RoomID)
+
+	return resp.RoomID, nil
+}
+
+// cachedAlias returns the room ID of a cached room alias.
+// The room ID is empty for aliases that do not exist.
+func (c *Client) cachedAlias(r string) (string, bool) {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
+	a, ok := c.aliases[r]
+	if !ok || a.expired() {
+		return "", false
+	}
+
+	return a.ID, true
+}
+
+// cacheAlias adds a room alias to the cache, and removes expired aliases.
+// An empty room ID caches an alias that does not exist.
+func (c *Client) cacheAlias(r, id string) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+
+	for k, a := range c.aliases {
+		if a.expired() {
+			delete(c.aliases, k)
+		}
+	}
+
+	c.aliases[r] = &alias{ID: id, Time: time.Now()}
+}
+
+// expired returns true if the alias should no longer be used from the cache.
+func (a *alias) expired() bool {
+	if a.ID == "" {
+		return time.Since(a.Time) > unknownAliasCacheTTL
+	}
+
+	return time.Since(a.Time) > aliasCacheTTL
+}
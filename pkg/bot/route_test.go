@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// routedAlerts describes the alerts routed to a room, by fingerprint, and the status of the message.
+type routedAlerts struct {
+	status string
+	alerts []string
+}
+
+func TestRouteAlerts(t *testing.T) {
+	message := decodeMessage(t, `{"status":"firing","groupKey":"group","alerts":[
+		{"fingerprint":"db-critical","status":"firing","labels":{"team":"db","severity":"critical"}},
+		{"fingerprint":"db-warning","status":"firing","labels":{"team":"db","severity":"warning"}},
+		{"fingerprint":"web","status":"firing","labels":{"team":"web"}},
+		{"fingerprint":"web-resolved","status":"resolved","labels":{"team":"web"}},
+		{"fingerprint":"other","status":"resolved","labels":{"team":"other"}}]}`)
+
+	critical := &Route{Rooms: []string{"!oncall:example.com"}, Matchers: []string{`severity="critical"`}}
+	db := &Route{Rooms: []string{"!db:example.com"}, Matchers: []string{`team="db"`}}
+	web := &Route{Rooms: []string{"!web:example.com", "!db:example.com"}, Matchers: []string{`team=~"web|www"`}}
+	catchAll := &Route{Rooms: []string{"!all:example.com"}}
+
+	tests := []struct {
+		name     string
+		routes   []*Route
+		expected map[string]routedAlerts
+	}{
+		{
+			name:   "first match",
+			routes: []*Route{critical, db, web},
+			expected: map[string]routedAlerts{
+				"!oncall:example.com": {status: "firing", alerts: []string{"db-critical"}},
+				"!db:example.com":     {status: "firing", alerts: []string{"db-warning", "web", "web-resolved"}},
+				"!web:example.com":    {status: "firing", alerts: []string{"web", "web-resolved"}},
+			},
+		},
+		{
+			name:   "continue",
+			routes: []*Route{{Rooms: critical.Rooms, Matchers: critical.Matchers, Continue: true}, db},
+			expected: map[string]routedAlerts{
+				"!oncall:example.com": {status: "firing", alerts: []string{"db-critical"}},
+				"!db:example.com":     {status: "firing", alerts: []string{"db-critical", "db-warning"}},
+			},
+		},
+		{
+			name:   "catch-all",
+			routes: []*Route{web, catchAll},
+			expected: map[string]routedAlerts{
+				"!web:example.com": {status: "firing", alerts: []string{"web", "web-resolved"}},
+				"!db:example.com":  {status: "firing", alerts: []string{"web", "web-resolved"}},
+				"!all:example.com": {status: "firing", alerts: []string{"db-critical", "db-warning", "other"}},
+			},
+		},
+		{
+			name:   "catch-all first",
+			routes: []*Route{catchAll, web},
+			expected: map[string]routedAlerts{
+				"!all:example.com": {status: "firing", alerts: []string{"db-critical", "db-warning", "web", "web-resolved", "other"}},
+			},
+		},
+		{
+			name:   "resolved only",
+			routes: []*Route{{Rooms: []string{"!other:example.com"}, Matchers: []string{`team="other"`}}},
+			expected: map[string]routedAlerts{
+				"!other:example.com": {status: "resolved", alerts: []string{"other"}},
+			},
+		},
+		{
+			name:     "no match",
+			routes:   []*Route{{Rooms: []string{"!none:example.com"}, Matchers: []string{`team="none"`}}},
+			expected: map[string]routedAlerts{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, nil, &ClientConfig{Routes: test.routes})
+
+			messages, err := client.RouteAlerts(message)
+			if err != nil {
+				t.Fatalf("Error routing alerts: %s", err)
+			}
+
+			routed := make(map[string]routedAlerts, len(messages))
+
+			for roomID, msg := range messages {
+				if msg.GroupKey != message.GroupKey {
+					t.Errorf("Expected the message for %s to keep the group key, got %q", roomID, msg.GroupKey)
+				}
+
+				r := routedAlerts{status: msg.Status}
+				for _, a := range msg.Alerts {
+					r.alerts = append(r.alerts, a.Fingerprint)
+				}
+
+				routed[roomID] = r
+			}
+
+			if !reflect.DeepEqual(routed, test.expected) {
+				t.Errorf("Unexpected routing:\nexpected: %+v\ngot:      %+v", test.expected, routed)
+			}
+		})
+	}
+
+	if len(message.Alerts) != 5 || message.Status != "firing" {
+		t.Errorf("Expected the original message to be unchanged, got %+v", message)
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []*Route
+		err    error
+	}{
+		{name: "valid", routes: []*Route{{Name: "a", Rooms: []string{"!a:example.com"}}, {Rooms: []string{"!b:example.com"}}}},
+		{name: "no rooms", routes: []*Route{{Name: "a"}}, err: errInvalidRoute},
+		{name: "nil", routes: []*Route{nil}, err: errInvalidRoute},
+		{name: "invalid matchers", routes: []*Route{{Rooms: []string{"!a:example.com"}, Matchers: []string{"a=~("}}}, err: errInvalidRoute},
+		{
+			name:   "duplicate name",
+			routes: []*Route{{Name: "a", Rooms: []string{"!a:example.com"}}, {Name: "a", Rooms: []string{"!b:example.com"}}},
+			err:    errDuplicateRoute,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := validateRoutes(test.routes); !errors.Is(err, test.err) {
+				t.Errorf("Expected error %v, got %v", test.err, err)
+			}
+		})
+	}
+}
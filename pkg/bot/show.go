@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// AlertDetails represents the information for the details of a single alert.
+// It is used for formatting.
+type AlertDetails struct {
+	*alertmanager.Alert
+	AlertmanagerURL string // URL of the Alertmanager the alert was retrieved from.
+	AlertURL        string // URL of the alert in the Alertmanager UI.
+}
+
+// showCommand returns the `show` command.
+func (c *Client) showCommand() *bot.Command {
+	return &bot.Command{
+		Summary: "Show the details of an alert.",
+		Description: "Show all labels, annotations and the state of an alert using its `fingerprint`, for example:\n" +
+			"```\nshow 04e45af092081699\n```\n" +
+			"The Alertmanager can be selected using `--am=<name>`.\n",
+		MessageHandler: c.handler("show", func(sender string, args []string) (*bot.Message, error) {
+			am, args := alertmanagerArg(args)
+			if len(args) != 1 || args[0] == "" {
+				return insufficientArgs()
+			}
+
+			return c.ShowAlert(am, args[0])
+		}),
+	}
+}
+
+// ShowAlert returns a message containing the details of the alert with the given fingerprint.
+// All selected Alertmanagers are searched if the name is empty or `all`.
+// An error is returned if the alert was not found, or one or more Alertmanagers could not be searched.
+func (c *Client) ShowAlert(am, fingerprint string) (*bot.Message, error) {
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	var plain, formatted, errs []string
+
+	for _, name := range names {
+		client := c.Alertmanagers[name]
+
+		alert, err := client.GetAlert(fingerprint)
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			continue
+		}
+
+		if alert == nil {
+			continue
+		}
+
+		if err = c.annotateAcks(context.TODO(), client, []*alertmanager.Alert{alert}); err != nil {
+			log.Printf("Error retrieving acknowledgements: %s", err)
+		}
+
+		if c.multipleAlertmanagers() {
+			alert.Alertmanager = name
+		}
+
+		p, f := c.formatter().FormatAlertDetails(&AlertDetails{
+			Alert:           alert,
+			AlertmanagerURL: client.URL(),
+			AlertURL:        alertURL(client.URL(), alert),
+		})
+
+		plain = append(plain, p)
+		formatted = append(formatted, f)
+	}
+
+	if len(plain) == 0 {
+		err = fmt.Errorf("%w: %s", errNoAlert, fingerprint)
+
+		return bot.NewTextMessage(strings.Join(append(errs, err.Error()), "\n")), err
+	}
+
+	return withErrors(errs, strings.Join(plain, "\n"), strings.Join(formatted, "<br/>")), commandError(errs)
+}
+
+// alertURL returns the URL of an alert in the Alertmanager UI.
+func alertURL(base string, alert *alertmanager.Alert) string {
+	matchers := make([]string, 0, len(alert.Labels))
+	for name, value := range alert.Labels {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", name, value))
+	}
+
+	sort.Strings(matchers)
+
+	query := url.Values{
+		"silenced":  {"true"},
+		"inhibited": {"true"},
+		"filter":    {"{" + strings.Join(matchers, ",") + "}"},
+	}
+
+	return base + "/#/alerts?" + query.Encode()
+}
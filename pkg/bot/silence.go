@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+var errSilenceExpired = errors.New("silence has expired")
+
+// ExtendSilence extends the end of a silence by the given duration.
+func (c *Client) ExtendSilence(author, am, id, durationStr string) (string, error) {
+	duration, err := parseDuration(durationStr)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	return c.updateSilence(author, am, id, "extended by "+durationStr, func(s *types.Silence) {
+		s.EndsAt = s.EndsAt.Add(duration)
+	})
+}
+
+// EditSilence replaces the matchers of a silence.
+func (c *Client) EditSilence(author, am, id, matchers string) (string, error) {
+	parsed, err := labels.ParseMatchers(matchers)
+	if err != nil {
+		err = fmt.Errorf("invalid matchers: %w", err)
+
+		return err.Error(), err
+	}
+
+	return c.updateSilence(author, am, id, "changed matchers to "+labels.Matchers(parsed).String(),
+		func(s *types.Silence) {
+			s.Matchers = parsed
+		})
+}
+
+// CommentSilence adds a comment to a silence.
+func (c *Client) CommentSilence(author, am, id, comment string) (string, error) {
+	return c.updateSilence(author, am, id, comment, func(s *types.Silence) {})
+}
+
+// updateSilence retrieves a silence from the first of the selected Alertmanagers that contains it,
+// modifies it and stores it again.
+// The change is recorded with the author in the comment of the silence.
+func (c *Client) updateSilence(author, am, id, change string, modify func(*types.Silence)) (string, error) {
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	name, silence, err := c.getSilence(names, id)
+	if err != nil {
+		return fmt.Sprintf("Error retrieving %s: %s", id, err), err
+	}
+
+	if silence.Status.State == types.SilenceStateExpired {
+		return fmt.Sprintf("Error updating %s: %s", id, errSilenceExpired), errSilenceExpired
+	}
+
+	modify(silence)
+
+	if !silence.EndsAt.After(time.Now()) {
+		return fmt.Sprintf("Error updating %s: %s", id, errSilenceExpired), errSilenceExpired
+	}
+
+	silence.Comment = strings.TrimSpace(fmt.Sprintf("%s\n%s: %s", silence.Comment, author, change))
+
+	newID, err := c.Alertmanagers[name].Silence.Set(context.Background(), *silence)
+	if err != nil {
+		return fmt.Sprintf("Error updating %s: %s", id, err), err
+	}
+
+	if newID != id {
+		return fmt.Sprintf("Silence *%s* replaced by *%s*", id, newID), nil
+	}
+
+	return fmt.Sprintf("Silence *%s* updated", id), nil
+}
+
+// getSilence retrieves a silence from the first of the given Alertmanagers that contains it,
+// and returns the name of that Alertmanager.
+func (c *Client) getSilence(names []string, id string) (string, *types.Silence, error) {
+	errs := make([]string, 0, len(names))
+
+	for _, name := range names {
+		silence, err := c.Alertmanagers[name].Silence.Get(context.Background(), id)
+		if err == nil {
+			return name, silence, nil
+		}
+
+		if len(names) == 1 {
+			return "", nil, err
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+	}
+
+	return "", nil, fmt.Errorf("%w: %s", errNoSilence, strings.Join(errs, "; "))
+}
+
+// silenceMatch contains the matchers of a silence in an Alertmanager, and the firing alerts it matches.
+type silenceMatch struct {
+	name     string
+	matchers labels.Matchers
+	alerts   []*alertmanager.Alert
+}
+
+// PreviewSilence returns the firing alerts that a silence with the given matchers or fingerprint would match.
+// The alerts of all selected Alertmanagers are combined if the name is empty or `all`.
+// An error is returned if the alerts of one or more Alertmanagers could not be matched.
+func (c *Client) PreviewSilence(am, matchers string) (*bot.Message, error) {
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return errorMessage(err)
+	}
+
+	matches, errs := c.matchSilence(names, matchers)
+	alerts := matchedAlerts(matches)
+
+	return c.matchesMessage(append(errs, matchCount(alerts)), alerts), commandError(errs)
+}
+
+// matchSilence resolves the matchers or fingerprint of a silence in each of the given Alertmanagers,
+// and retrieves the firing alerts that the silence matches, including silenced and inhibited alerts.
+// Alertmanagers that return an error are skipped, and the errors are returned as messages.
+func (c *Client) matchSilence(names []string, matchers string) ([]*silenceMatch, []string) {
+	var (
+		matches []*silenceMatch
+		errs    []string
+	)
+
+	for _, name := range names {
+		parsed, err := c.silenceMatchers(c.Alertmanagers[name], matchers)
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			continue
+		}
+
+		filter := &alertmanager.AlertFilter{Active: true, Silenced: true, Inhibited: true}
+		for _, m := range parsed {
+			filter.Matchers = append(filter.Matchers, m.String())
+		}
+
+		alerts, alertErrs, err := c.alerts(name, filter)
+		if err != nil {
+			errs = append(errs, c.alertmanagerError(name, err))
+			continue
+		}
+
+		if len(alertErrs) > 0 {
+			errs = append(errs, alertErrs...)
+			continue
+		}
+
+		matches = append(matches, &silenceMatch{name: name, matchers: parsed, alerts: alerts})
+	}
+
+	return matches, errs
+}
+
+// matchedAlerts returns the alerts of all matches.
+func matchedAlerts(matches []*silenceMatch) []*alertmanager.Alert {
+	var alerts []*alertmanager.Alert
+	for _, m := range matches {
+		alerts = append(alerts, m.alerts...)
+	}
+
+	return alerts
+}
+
+// matchCount returns a message containing the number of matched alerts.
+func matchCount(alerts []*alertmanager.Alert) string {
+	if len(alerts) == 0 {
+		return "The silence matches no firing alerts."
+	}
+
+	return fmt.Sprintf("The silence matches %d firing alert(s):", len(alerts))
+}
+
+// matchesMessage returns a message with the given Markdown messages followed by the matched alerts.
+// A summary of the alerts is shown if there are more alerts than fit on a page.
+func (c *Client) matchesMessage(messages []string, alerts []*alertmanager.Alert) *bot.Message {
+	msg := bot.NewMarkdownMessage(strings.Join(messages, "\n\n"))
+	if len(alerts) == 0 {
+		return msg
+	}
+
+	var plain, formatted string
+	if len(alerts) <= listPageSize {
+		plain, formatted = c.formatter().FormatAlerts(alerts, false)
+	} else {
+		plain, formatted = c.formatter().FormatAlertList(NewAlertList(alerts, ""))
+	}
+
+	msg.Body += "\n" + plain
+	msg.FormattedBody += formatted
+
+	return msg
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// silenceHandlers returns handlers for an Alertmanager with the given alerts and no silences,
+// that count the silences that are created.
+func silenceHandlers(created *int, alerts string) testHandlers {
+	return testHandlers{
+		"/api/v2/silences": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				_, _ = w.Write([]byte(`[]`))
+
+				return
+			}
+
+			*created++
+
+			_, _ = w.Write([]byte(`{"silenceID":"7f3c4c8e"}`))
+		},
+		"/api/v2/alerts": respond(alerts),
+	}
+}
+
+func TestSilenceAddStart(t *testing.T) {
+	halfHourAgo := time.Now().Add(-30 * time.Minute).Format("2006-01-02T15:04")
+
+	tests := []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{name: "now", args: []string{"1h", "job=a"}},
+		{name: "future", args: []string{"1h", "--at", "+30m", "job=a"}},
+		{name: "past and active", args: []string{"1h", "--at", halfHourAgo, "job=a"}},
+		{name: "past and ended", args: []string{"1h", "--at", "2000-01-01", "job=a"}, err: errSilenceEnded},
+		{name: "ended by duration", args: []string{"10m", "--at", halfHourAgo, "job=a"}, err: errSilenceEnded},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			created := 0
+
+			client := newTestClient(t, silenceHandlers(&created, `[]`), nil)
+
+			_, err := client.silenceAdd("@user:example.com", "", test.args)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+
+			if (test.err == nil) != (created == 1) {
+				t.Errorf("Unexpected number of silences created: %d", created)
+			}
+		})
+	}
+}
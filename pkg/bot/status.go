@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bot "gitlab.com/silkeh/matrix-bot"
+
+	"github.com/silkeh/alertmanager_matrix/pkg/alertmanager"
+)
+
+// statusCommand returns the `status` command.
+func (c *Client) statusCommand() *bot.Command {
+	return &bot.Command{
+		Summary: "Show the status of the Alertmanager replicas.",
+		Description: "Show the health and cluster status of each replica of the Alertmanagers.\n\n" +
+			"The Alertmanager can be selected using `--am=<name>`, for example:\n" +
+			"```\nstatus --am=prod\n```\n",
+		MessageHandler: c.handler("status", func(sender string, args []string) (*bot.Message, error) {
+			am, _ := alertmanagerArg(args)
+			md, err := c.Status(am)
+
+			return bot.NewMarkdownMessage(md), err
+		}),
+	}
+}
+
+// Status returns a Markdown formatted message containing the status of the replicas of the given Alertmanager.
+// The replicas of all selected Alertmanagers are checked if the name is empty or `all`.
+// Unhealthy replicas are reported in the message, and are not an error.
+func (c *Client) Status(am string) (string, error) {
+	names, err := c.alertmanagers(am)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	parts := make([]string, 0, len(names))
+
+	for _, name := range names {
+		statuses := c.Alertmanagers[name].CheckReplicas(context.TODO())
+		lines := make([]string, len(statuses))
+
+		for i, s := range statuses {
+			lines[i] = formatReplicaStatus(s)
+		}
+
+		md := strings.Join(lines, "\n")
+		if c.multipleAlertmanagers() {
+			md = fmt.Sprintf("**Alertmanager %s**\n\n%s", name, md)
+		}
+
+		parts = append(parts, md)
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// formatReplicaStatus formats the status of a replica as a Markdown list item.
+func formatReplicaStatus(s *alertmanager.ReplicaStatus) string {
+	if !s.Healthy {
+		return fmt.Sprintf("- ❌ `%s`: %s", s.URL, s.Error)
+	}
+
+	if s.Status == nil {
+		return fmt.Sprintf("- ✅ `%s`", s.URL)
+	}
+
+	cluster := s.Status.Cluster.Status
+	if cluster == "" {
+		cluster = "disabled"
+	}
+
+	return fmt.Sprintf("- ✅ `%s`: version %s, up since %s, cluster %s with %d peer(s)",
+		s.URL, s.Status.VersionInfo.Version, s.Status.Uptime.Format("2006-01-02 15:04:05 MST"),
+		cluster, len(s.Status.Cluster.Peers))
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// store is a simple key/value store that is optionally persisted to disk.
+// Values are grouped in named buckets.
+type store struct {
+	mu   sync.Mutex
+	path string
+	data map[string]map[string]*storeEntry
+}
+
+// storeEntry represents a single value in the store.
+type storeEntry struct {
+	Value string    `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// newStore creates a store persisted to the given path.
+// Existing contents are loaded from the file if it exists.
+// The store is kept in memory only if path is empty.
+func newStore(path string) (*store, error) {
+	s := &store{
+		path: path,
+		data: make(map[string]map[string]*storeEntry),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	contents, err := os.ReadFile(path) //nolint:gosec // reading the configured file is the point
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state file: %w", err)
+	}
+
+	if err = json.Unmarshal(contents, &s.data); err != nil {
+		return nil, fmt.Errorf("unable to parse state file: %w", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the value for a key in a bucket.
+func (s *store) Get(bucket, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data[bucket][key]
+	if !ok {
+		return "", false
+	}
+
+	return e.Value, true
+}
+
+// All returns a copy of all values in a bucket.
+func (s *store) All(bucket string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]string, len(s.data[bucket]))
+	for k, e := range s.data[bucket] {
+		values[k] = e.Value
+	}
+
+	return values
+}
+
+// Set sets the value for a key in a bucket and persists the store.
+func (s *store) Set(bucket, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[bucket] == nil {
+		s.data[bucket] = make(map[string]*storeEntry)
+	}
+
+	s.data[bucket][key] = &storeEntry{Value: value, Time: time.Now()}
+
+	return s.save()
+}
+
+// Delete removes a key from a bucket and persists the store.
+func (s *store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[bucket][key]; !ok {
+		return nil
+	}
+
+	delete(s.data[bucket], key)
+
+	if len(s.data[bucket]) == 0 {
+		delete(s.data, bucket)
+	}
+
+	return s.save()
+}
+
+// Prune removes all keys from a bucket that were set longer ago than the given age,
+// and persists the store.
+func (s *store) Prune(bucket string, age time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := false
+
+	for k, e := range s.data[bucket] {
+		if time.Since(e.Time) > age {
+			delete(s.data[bucket], k)
+
+			pruned = true
+		}
+	}
+
+	if !pruned {
+		return nil
+	}
+
+	if len(s.data[bucket]) == 0 {
+		delete(s.data, bucket)
+	}
+
+	return s.save()
+}
+
+// save writes the store to disk, if a path is configured.
+// The file is replaced atomically to avoid corruption.
+func (s *store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	contents, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("unable to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	if _, err = tmp.Write(contents); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	return nil
+}
@@ -1,12 +1,27 @@
 package bot
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
-var durationRegex = regexp.MustCompile(`(\d+)(\w)`)
+var durationRegex = regexp.MustCompile(`^(\d+)([dwy])$`)
+
+var errInvalidTime = errors.New("invalid time")
+
+// timeFormats contains the formats accepted by parseTime, in the local time zone if none is given.
+var timeFormats = []string{ //nolint:gochecknoglobals
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
 
 // Additional durations.
 const (
@@ -39,3 +54,35 @@ func parseDuration(s string) (time.Duration, error) {
 		return time.ParseDuration(s)
 	}
 }
+
+// contains returns true if the list contains the given element.
+func contains(list []string, element string) bool {
+	for _, a := range list {
+		if a == element {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTime parses an absolute time in one of the timeFormats,
+// or a time relative to now as a duration prefixed with `+`, eg: `+2h`.
+func parseTime(s string) (time.Time, error) {
+	if strings.HasPrefix(s, "+") {
+		d, err := parseDuration(strings.TrimPrefix(s, "+"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %q", errInvalidTime, s)
+		}
+
+		return time.Now().Add(d), nil
+	}
+
+	for _, format := range timeFormats {
+		if t, err := time.ParseInLocation(format, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", errInvalidTime, s)
+}
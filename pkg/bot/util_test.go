@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected time.Duration
+		valid    bool
+	}{
+		{str: "30m", expected: 30 * time.Minute, valid: true},
+		{str: "1h30m", expected: 90 * time.Minute, valid: true},
+		{str: "2d", expected: 2 * Day, valid: true},
+		{str: "1w", expected: Week, valid: true},
+		{str: "1y", expected: Year, valid: true},
+		{str: "1d12h"},
+		{str: "db1w"},
+		{str: `host="db1w"`},
+		{str: "1x"},
+	}
+
+	for _, test := range tests {
+		d, err := parseDuration(test.str)
+		if (err == nil) != test.valid || d != test.expected {
+			t.Errorf("Expected %s (valid: %v) for %q, got %s (%v)", test.expected, test.valid, test.str, d, err)
+		}
+	}
+}